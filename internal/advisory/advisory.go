@@ -0,0 +1,282 @@
+// Package advisory loads security advisories in the OSV format and matches
+// them against the versions of installed tools.
+package advisory
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/toolctl/toolctl/internal/httpclient"
+)
+
+// Advisory is an OSV advisory, see https://ossf.github.io/osv-schema/.
+type Advisory struct {
+	ID        string     `json:"id"`
+	Summary   string     `json:"summary"`
+	Aliases   []string   `json:"aliases"`
+	Withdrawn string     `json:"withdrawn"`
+	Affected  []Affected `json:"affected"`
+}
+
+// Affected describes a package and the versions of it that an advisory
+// applies to.
+type Affected struct {
+	Package  Package  `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// Package identifies the affected package. Advisories apply to a tool if the
+// package name matches the name of the tool.
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// Range is a range of affected versions, given as a list of events.
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event introduces or ends a range of affected versions.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Match is an advisory that applies to a tool version, together with the
+// lowest version that fixes it. Fixed is nil if no fixed version is known.
+type Match struct {
+	Advisory Advisory
+	Fixed    *semver.Version
+}
+
+// Database holds the advisories loaded from an advisory database.
+type Database struct {
+	Advisories []Advisory
+}
+
+// Load loads an advisory database from a local directory, a local ZIP file or
+// an HTTP(S) URL serving a ZIP file or a single JSON advisory. Directories
+// are searched recursively for JSON files, which is how OSV mirrors are
+// usually laid out.
+func Load(location string) (db Database, err error) {
+	if strings.HasPrefix(location, "http://") ||
+		strings.HasPrefix(location, "https://") {
+		var contents []byte
+		contents, err = download(location)
+		if err != nil {
+			return
+		}
+		return parse(location, contents)
+	}
+
+	fi, err := os.Stat(location)
+	if err != nil {
+		return
+	}
+	if !fi.IsDir() {
+		var contents []byte
+		contents, err = os.ReadFile(location)
+		if err != nil {
+			return
+		}
+		return parse(location, contents)
+	}
+
+	return loadFS(os.DirFS(location))
+}
+
+// download fetches the contents of the given URL.
+func download(url string) (contents []byte, err error) {
+	resp, err := httpclient.Get(url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		err = fmt.Errorf(
+			"failed to download advisory database: unexpected status code: %d",
+			resp.StatusCode,
+		)
+		return
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// parse parses the contents of a ZIP file or a JSON advisory.
+func parse(location string, contents []byte) (db Database, err error) {
+	if bytes.HasPrefix(contents, []byte("PK\x03\x04")) {
+		var zipReader *zip.Reader
+		zipReader, err = zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+		if err != nil {
+			return
+		}
+		return loadFS(zipReader)
+	}
+
+	advisory, err := parseAdvisory(contents)
+	if err != nil {
+		err = fmt.Errorf("failed to parse %s: %w", location, err)
+		return
+	}
+	db.Advisories = append(db.Advisories, advisory)
+	return
+}
+
+// loadFS loads all JSON advisories from the given file system.
+func loadFS(fsys fs.FS) (db Database, err error) {
+	err = fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(filePath) != ".json" {
+			return nil
+		}
+
+		contents, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+		advisory, err := parseAdvisory(contents)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+		db.Advisories = append(db.Advisories, advisory)
+		return nil
+	})
+	return
+}
+
+// parseAdvisory parses a single JSON advisory.
+func parseAdvisory(contents []byte) (advisory Advisory, err error) {
+	err = json.Unmarshal(contents, &advisory)
+	if err != nil {
+		return
+	}
+	if advisory.ID == "" {
+		err = fmt.Errorf("advisory has no id")
+	}
+	return
+}
+
+// Find returns the advisories that apply to the given version of a tool,
+// sorted by advisory ID. Withdrawn advisories are ignored.
+func (db Database) Find(toolName string, version *semver.Version) (matches []Match) {
+	for _, advisory := range db.Advisories {
+		if advisory.Withdrawn != "" {
+			continue
+		}
+		for _, affected := range advisory.Affected {
+			if !strings.EqualFold(affected.Package.Name, toolName) {
+				continue
+			}
+			if isAffected, fixed := affected.affects(version); isAffected {
+				matches = append(matches, Match{Advisory: advisory, Fixed: fixed})
+				break
+			}
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		return strings.Compare(a.Advisory.ID, b.Advisory.ID)
+	})
+	return
+}
+
+// affects checks if the given version is affected, either because it is
+// listed explicitly or because it lies within one of the SEMVER ranges. If
+// it is affected, the lowest version that fixes it is returned as well.
+func (affected Affected) affects(version *semver.Version) (isAffected bool, fixed *semver.Version) {
+	for _, v := range affected.Versions {
+		listedVersion, err := semver.NewVersion(v)
+		if err == nil && listedVersion.Equal(version) {
+			isAffected = true
+		}
+	}
+
+	for _, r := range affected.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+		inRange, rangeFixed := r.affects(version)
+		if !inRange {
+			continue
+		}
+		isAffected = true
+		if rangeFixed != nil && (fixed == nil || rangeFixed.LessThan(fixed)) {
+			fixed = rangeFixed
+		}
+	}
+
+	return
+}
+
+// affects evaluates the events of a range in version order, as described in
+// the OSV schema, and returns the fixed version that ends the range the given
+// version lies in.
+func (r Range) affects(version *semver.Version) (isAffected bool, fixed *semver.Version) {
+	type parsedEvent struct {
+		event   Event
+		version *semver.Version
+	}
+
+	var events []parsedEvent
+	for _, event := range r.Events {
+		v := event.Introduced + event.Fixed + event.LastAffected + event.Limit
+		if event.Introduced == "0" {
+			v = "0.0.0"
+		}
+		eventVersion, err := semver.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		events = append(events, parsedEvent{event: event, version: eventVersion})
+	}
+	slices.SortStableFunc(events, func(a, b parsedEvent) int {
+		return a.version.Compare(b.version)
+	})
+
+	for i, e := range events {
+		switch {
+		case e.event.Introduced != "" && !version.LessThan(e.version):
+			isAffected = true
+			fixed = nil
+			// The next fixed event ends this range
+			for _, next := range events[i+1:] {
+				if next.event.Fixed != "" {
+					fixed = next.version
+					break
+				}
+				if next.event.LastAffected != "" || next.event.Introduced != "" {
+					break
+				}
+			}
+		case e.event.Fixed != "" && !version.LessThan(e.version):
+			isAffected = false
+		case e.event.LastAffected != "" && version.GreaterThan(e.version):
+			isAffected = false
+		case e.event.Limit != "" && !version.LessThan(e.version):
+			isAffected = false
+		}
+	}
+
+	if !isAffected {
+		fixed = nil
+	}
+	return
+}
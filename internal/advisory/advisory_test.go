@@ -0,0 +1,246 @@
+package advisory_test
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/google/go-cmp/cmp"
+	"github.com/toolctl/toolctl/internal/advisory"
+)
+
+const testAdvisories = `{
+  "id": "OSV-2024-0001",
+  "summary": "Remote code execution",
+  "affected": [
+    {
+      "package": {"ecosystem": "toolctl", "name": "toolctl-test-tool"},
+      "ranges": [
+        {
+          "type": "SEMVER",
+          "events": [
+            {"introduced": "0"},
+            {"fixed": "0.1.1"},
+            {"introduced": "0.2.0"},
+            {"fixed": "0.2.3"}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+const testAdvisoriesLastAffected = `{
+  "id": "OSV-2024-0002",
+  "summary": "Denial of service",
+  "affected": [
+    {
+      "package": {"name": "toolctl-test-tool"},
+      "ranges": [
+        {
+          "type": "SEMVER",
+          "events": [
+            {"introduced": "0.2.1"},
+            {"last_affected": "0.2.5"}
+          ]
+        }
+      ],
+      "versions": ["0.0.9"]
+    }
+  ]
+}`
+
+const testAdvisoriesWithdrawn = `{
+  "id": "OSV-2024-0003",
+  "withdrawn": "2024-06-01T00:00:00Z",
+  "affected": [
+    {
+      "package": {"name": "toolctl-test-tool"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]
+    }
+  ]
+}`
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"toolctl/OSV-2024-0001.json": testAdvisories,
+		"toolctl/OSV-2024-0002.json": testAdvisoriesLastAffected,
+		"toolctl/OSV-2024-0003.json": testAdvisoriesWithdrawn,
+		"README.md":                  "not an advisory",
+	}
+	for name, contents := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := advisory.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		version string
+		want    map[string]string
+	}{
+		{
+			name:    "introduced at zero",
+			tool:    "toolctl-test-tool",
+			version: "0.1.0",
+			want:    map[string]string{"OSV-2024-0001": "0.1.1"},
+		},
+		{
+			name:    "fixed version is not affected",
+			tool:    "toolctl-test-tool",
+			version: "0.1.1",
+			want:    map[string]string{},
+		},
+		{
+			name:    "second range",
+			tool:    "toolctl-test-tool",
+			version: "0.2.2",
+			want: map[string]string{
+				"OSV-2024-0001": "0.2.3",
+				"OSV-2024-0002": "",
+			},
+		},
+		{
+			name:    "last affected",
+			tool:    "toolctl-test-tool",
+			version: "0.2.5",
+			want:    map[string]string{"OSV-2024-0002": ""},
+		},
+		{
+			name:    "after last affected",
+			tool:    "toolctl-test-tool",
+			version: "0.2.6",
+			want:    map[string]string{},
+		},
+		{
+			name:    "explicitly listed version",
+			tool:    "toolctl-test-tool",
+			version: "0.0.9",
+			want:    map[string]string{"OSV-2024-0001": "0.1.1", "OSV-2024-0002": ""},
+		},
+		{
+			name:    "other tool",
+			tool:    "toolctl-other-test-tool",
+			version: "0.1.0",
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for _, match := range db.Find(tt.tool, semver.MustParse(tt.version)) {
+				got[match.Advisory.ID] = ""
+				if match.Fixed != nil {
+					got[match.Advisory.ID] = match.Fixed.String()
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Find() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// A ZIP file, like the OSV bulk downloads
+	zipPath := filepath.Join(dir, "all.zip")
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipWriter := zip.NewWriter(zipFile)
+	w, err := zipWriter.Create("OSV-2024-0001.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write([]byte(testAdvisories))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = zipWriter.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = zipFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	err = os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("{}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		location   string
+		wantIDs    []string
+		wantErrStr string
+	}{
+		{
+			name:     "local ZIP file",
+			location: zipPath,
+			wantIDs:  []string{"OSV-2024-0001"},
+		},
+		{
+			name:     "ZIP file URL",
+			location: server.URL + "/all.zip",
+			wantIDs:  []string{"OSV-2024-0001"},
+		},
+		{
+			name:       "URL not found",
+			location:   server.URL + "/missing.zip",
+			wantErrStr: "failed to download advisory database: unexpected status code: 404",
+		},
+		{
+			name:       "invalid advisory",
+			location:   filepath.Join(dir, "invalid.json"),
+			wantErrStr: "failed to parse " + filepath.Join(dir, "invalid.json") + ": advisory has no id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := advisory.Load(tt.location)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil {
+				if err.Error() != tt.wantErrStr {
+					t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErrStr)
+				}
+				return
+			}
+
+			var gotIDs []string
+			for _, a := range db.Advisories {
+				gotIDs = append(gotIDs, a.ID)
+			}
+			if diff := cmp.Diff(tt.wantIDs, gotIDs); diff != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,49 +4,64 @@
 package api
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/sysutil"
 )
 
-// Location represents the location of the API, currently remote or local.
-type Location uint32
-
-const (
-	// Local represents the local API location.
-	Local Location = iota
-	// Remote represents the remote API location.
-	Remote
-)
-
 // ToolctlAPI defines the interface that all toolctl APIs need to implement.
+// LocalAPIBasePath is empty for APIs that are not backed by the local file
+// system.
 type ToolctlAPI interface {
 	LocalAPIBasePath() string
 	LocalAPIFS() afero.Fs
-	Location() Location
 
 	GetContents(path string) (found bool, contents []byte, err error)
 	SaveContents(path string, data []byte) error
 }
 
-// New returns a new API instance, based on the specified command line flags
-// and the default location.
-func New(
-	localAPIFS afero.Fs, cmd *cobra.Command, defaultLocation Location,
-) (toolctlAPI ToolctlAPI, err error) {
+// RegistryEnv is the environment variable that selects the registry, if the
+// --registry flag isn't specified.
+const RegistryEnv = "TOOLCTL_REGISTRY"
+
+// New returns the API that tools are looked up in. The registry is taken from
+// the --registry flag or the TOOLCTL_REGISTRY environment variable. Otherwise,
+// the local API is used with --local, and the registries from the config
+// without it.
+func New(localAPIFS afero.Fs, cmd *cobra.Command) (toolctlAPI ToolctlAPI, err error) {
+	registry, options, err := registryFromFlags(localAPIFS, cmd)
+	if err != nil {
+		return
+	}
+	if registry == "" {
+		registry = os.Getenv(RegistryEnv)
+	}
+	if registry != "" {
+		return Open(registry, options)
+	}
+
 	var localFlag bool
 	localFlag, err = cmd.Flags().GetBool("local")
 	if err != nil {
 		return
 	}
+	if localFlag {
+		return newLocalAPIFromConfig(localAPIFS)
+	}
 
-	if localFlag || defaultLocation == Local {
-		var localAPIBasePath string
-		localAPIBasePath, err = sysutil.RequireConfigString("LocalAPIBasePath")
-		if err != nil {
-			return
-		}
-		return NewLocalAPI(localAPIFS, localAPIBasePath)
+	var registries []RegistryConfig
+	err = viper.UnmarshalKey("Registries", &registries)
+	if err != nil {
+		err = fmt.Errorf("invalid Registries: %w", err)
+		return
+	}
+	if len(registries) > 0 {
+		return newCompositeAPI(registries, options)
 	}
 
 	var remoteAPIBaseURL string
@@ -54,5 +69,98 @@ func New(
 	if err != nil {
 		return
 	}
-	return NewRemoteAPI(localAPIFS, remoteAPIBaseURL)
+	return Open(remoteAPIBaseURL, options)
+}
+
+// NewWritable returns the API that the api commands maintain. The registry is
+// taken from the --registry flag and defaults to the local API. The
+// TOOLCTL_REGISTRY environment variable is ignored, so that a registry meant
+// for reading is never written to by accident.
+func NewWritable(localAPIFS afero.Fs, cmd *cobra.Command) (toolctlAPI ToolctlAPI, err error) {
+	registry, options, err := registryFromFlags(localAPIFS, cmd)
+	if err != nil {
+		return
+	}
+	if registry != "" {
+		return Open(registry, options)
+	}
+	return newLocalAPIFromConfig(localAPIFS)
+}
+
+// registryFromFlags returns the registry that was selected on the command
+// line, if any, and the options to open registries with.
+func registryFromFlags(
+	localAPIFS afero.Fs, cmd *cobra.Command,
+) (registry string, options BackendOptions, err error) {
+	options.LocalAPIFS = localAPIFS
+	if cmd.Flags().Lookup("refresh") != nil {
+		options.Refresh, err = cmd.Flags().GetBool("refresh")
+		if err != nil {
+			return
+		}
+	}
+
+	if cmd.Flags().Lookup("registry") != nil {
+		registry, err = cmd.Flags().GetString("registry")
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// newLocalAPIFromConfig returns the local API in LocalAPIBasePath.
+func newLocalAPIFromConfig(localAPIFS afero.Fs) (ToolctlAPI, error) {
+	localAPIBasePath, err := sysutil.RequireConfigString("LocalAPIBasePath")
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalAPI(localAPIFS, localAPIBasePath)
+}
+
+// newCompositeAPI returns an API that layers the configured registries.
+func newCompositeAPI(
+	configs []RegistryConfig, options BackendOptions,
+) (toolctlAPI ToolctlAPI, err error) {
+	registries := make([]Registry, len(configs))
+	for i, config := range configs {
+		if config.Name == "" {
+			err = fmt.Errorf("invalid Registries: registry %d has no name", i+1)
+			return
+		}
+		registries[i].Name = config.Name
+
+		switch {
+		case config.URL != "" && config.Path == "":
+			registries[i].API, err = Open(config.URL, options)
+		case config.Path != "" && config.URL == "":
+			registries[i].API, err = NewLocalAPI(options.LocalAPIFS, config.Path)
+		default:
+			err = fmt.Errorf(
+				"invalid Registries: registry %s needs either a url or a path", config.Name,
+			)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return NewCompositeAPI(registries), nil
+}
+
+// withCache wraps the API in a cache, unless caching is disabled because the
+// CacheDir config key is empty.
+func withCache(options BackendOptions, toolctlAPI ToolctlAPI, baseURL string) (ToolctlAPI, error) {
+	cacheBaseDir := viper.GetString("CacheDir")
+	if cacheBaseDir == "" {
+		return toolctlAPI, nil
+	}
+
+	ttl, err := time.ParseDuration(viper.GetString("APICacheTTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid APICacheTTL: %w", err)
+	}
+
+	return NewCachedAPI(
+		toolctlAPI, options.LocalAPIFS, CacheDir(cacheBaseDir, baseURL), ttl, options.Refresh,
+	), nil
 }
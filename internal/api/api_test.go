@@ -14,51 +14,39 @@ import (
 func TestNew(t *testing.T) {
 	testFS := afero.NewMemMapFs()
 	baseURL, _ := url.Parse("http://localhost/")
+	registryURL, _ := url.Parse("https://registry.example.com/v0/")
 	basePath := "/tmp/test"
 
-	type args struct {
-		localAPIFS afero.Fs
-		// cmd is set in the tests
-		defaultLocation Location
-	}
-
 	tests := []struct {
 		name               string
 		remoteAPIBaseURL   string
 		localAPIBasePath   string
 		localFlag          bool
 		localFlagUndefined bool
-		args               args
+		registryFlag       string
+		registryEnv        string
+		writable           bool
 		want               ToolctlAPI
 		wantErrStr         string
 	}{
 		{
 			name:             "remote",
 			remoteAPIBaseURL: baseURL.String(),
-			args: args{
-				localAPIFS:      testFS,
-				defaultLocation: Remote,
-			},
-			want: &remoteAPI{
-				localAPIFS: testFS,
-				baseURL:    baseURL,
+			want: &indexedAPI{
+				ToolctlAPI: &remoteAPI{
+					localAPIFS: testFS,
+					baseURL:    baseURL,
+				},
 			},
 		},
 		{
-			name: "remote without RemoteAPIBaseURL",
-			args: args{
-				localAPIFS:      testFS,
-				defaultLocation: Remote,
-			},
+			name:       "remote without RemoteAPIBaseURL",
 			wantErrStr: "config key 'RemoteAPIBaseURL' could not be found",
 		},
 		{
-			name:             "local",
+			name:             "writable",
 			localAPIBasePath: basePath,
-			args: args{
-				localAPIFS:      testFS,
-				defaultLocation: Local,
-			},
+			writable:         true,
 			want: &localAPI{
 				basePath:   basePath,
 				localAPIFS: testFS,
@@ -68,31 +56,77 @@ func TestNew(t *testing.T) {
 			name:             "local through flag",
 			localAPIBasePath: basePath,
 			localFlag:        true,
-			args: args{
-				localAPIFS:      testFS,
-				defaultLocation: Remote,
-			},
 			want: &localAPI{
 				basePath:   basePath,
 				localAPIFS: testFS,
 			},
 		},
 		{
-			name: "local without LocalAPIBasePath",
-			args: args{
-				localAPIFS:      testFS,
-				defaultLocation: Local,
-			},
+			name:       "writable without LocalAPIBasePath",
+			writable:   true,
 			wantErrStr: "config key 'LocalAPIBasePath' could not be found",
 		},
 		{
 			name:               "local flag undefined",
 			localFlagUndefined: true,
-			args: args{
-				localAPIFS:      testFS,
-				defaultLocation: Remote,
+			wantErrStr:         "flag accessed but not defined: local",
+		},
+		{
+			name:             "file registry through flag",
+			remoteAPIBaseURL: baseURL.String(),
+			registryFlag:     "file:///tmp/other",
+			want: &localAPI{
+				basePath:   "/tmp/other",
+				localAPIFS: testFS,
+			},
+		},
+		{
+			name:         "registry path through flag",
+			registryFlag: "../api/v0",
+			want: &localAPI{
+				basePath:   "../api/v0",
+				localAPIFS: testFS,
 			},
-			wantErrStr: "flag accessed but not defined: local",
+		},
+		{
+			name:        "https registry through environment variable",
+			registryEnv: registryURL.String(),
+			want: &indexedAPI{
+				ToolctlAPI: &remoteAPI{
+					localAPIFS: testFS,
+					baseURL:    registryURL,
+				},
+			},
+		},
+		{
+			name:             "writable ignores environment variable",
+			localAPIBasePath: basePath,
+			registryEnv:      registryURL.String(),
+			writable:         true,
+			want: &localAPI{
+				basePath:   basePath,
+				localAPIFS: testFS,
+			},
+		},
+		{
+			name:         "registry flag takes precedence over environment variable",
+			registryFlag: "file:///tmp/other",
+			registryEnv:  registryURL.String(),
+			want: &localAPI{
+				basePath:   "/tmp/other",
+				localAPIFS: testFS,
+			},
+		},
+		{
+			name:         "unsupported registry scheme",
+			registryFlag: "ftp://registry.example.com/v0/",
+			wantErrStr: "invalid registry ftp://registry.example.com/v0/: " +
+				"unsupported scheme ftp, must be one of file, http, https, oci, oci+http, s3",
+		},
+		{
+			name:         "file registry with host",
+			registryFlag: "file://registry.example.com/v0",
+			wantErrStr:   "invalid registry file://registry.example.com/v0: file URLs must not have a host",
 		},
 	}
 
@@ -101,12 +135,19 @@ func TestNew(t *testing.T) {
 		if !tt.localFlagUndefined {
 			cmd.Flags().Bool("local", tt.localFlag, "")
 		}
+		cmd.Flags().String("registry", tt.registryFlag, "")
 
 		viper.Set("RemoteAPIBaseURL", tt.remoteAPIBaseURL)
 		viper.Set("LocalAPIBasePath", tt.localAPIBasePath)
 
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := New(tt.args.localAPIFS, cmd, tt.args.defaultLocation)
+			t.Setenv(RegistryEnv, tt.registryEnv)
+
+			newAPI := New
+			if tt.writable {
+				newAPI = NewWritable
+			}
+			got, err := newAPI(testFS, cmd)
 			if (err == nil) != (tt.wantErrStr == "") {
 				t.Errorf("New() error = %v, wantErr %v", err, (tt.wantErrStr != ""))
 				return
@@ -0,0 +1,89 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// BackendOptions are passed to the backends when opening a registry.
+type BackendOptions struct {
+	// LocalAPIFS is the file system of local registries and of the cache.
+	LocalAPIFS afero.Fs
+	// Refresh forces cached responses to be revalidated.
+	Refresh bool
+}
+
+// BackendFactory returns an API for a registry URL.
+type BackendFactory func(registryURL *url.URL, options BackendOptions) (ToolctlAPI, error)
+
+var backendFactories = map[string]BackendFactory{
+	"file":     newFileBackend,
+	"http":     newHTTPBackend,
+	"https":    newHTTPBackend,
+	"oci":      newOCIBackend,
+	"oci+http": newOCIBackend,
+	"s3":       newS3Backend,
+}
+
+// RegisterBackend makes a backend available for registry URLs with the given
+// scheme.
+func RegisterBackend(scheme string, factory BackendFactory) {
+	backendFactories[scheme] = factory
+}
+
+// Open returns the API for a registry URL, using the backend that is
+// registered for its scheme. URLs without a scheme are paths of local
+// registries.
+func Open(registry string, options BackendOptions) (ToolctlAPI, error) {
+	registryURL, err := url.Parse(registry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry %s: %w", registry, err)
+	}
+	if registryURL.Scheme == "" {
+		registryURL = &url.URL{Scheme: "file", Path: registry}
+	}
+
+	factory, ok := backendFactories[registryURL.Scheme]
+	if !ok {
+		schemes := make([]string, 0, len(backendFactories))
+		for scheme := range backendFactories {
+			schemes = append(schemes, scheme)
+		}
+		sort.Strings(schemes)
+		return nil, fmt.Errorf(
+			"invalid registry %s: unsupported scheme %s, must be one of %s",
+			registry, registryURL.Scheme, strings.Join(schemes, ", "),
+		)
+	}
+	return factory(registryURL, options)
+}
+
+// newFileBackend returns a local API for file:// URLs.
+func newFileBackend(registryURL *url.URL, options BackendOptions) (ToolctlAPI, error) {
+	if registryURL.Host != "" && registryURL.Host != "localhost" {
+		return nil, fmt.Errorf(
+			"invalid registry %s: file URLs must not have a host", registryURL,
+		)
+	}
+	return NewLocalAPI(options.LocalAPIFS, registryURL.Path)
+}
+
+// newHTTPBackend returns a remote API for http:// and https:// URLs, which
+// caches its responses and prefers the index.
+func newHTTPBackend(registryURL *url.URL, options BackendOptions) (toolctlAPI ToolctlAPI, err error) {
+	baseURL := registryURL.String()
+	toolctlAPI, err = NewRemoteAPI(options.LocalAPIFS, baseURL)
+	if err != nil {
+		return
+	}
+	toolctlAPI, err = withCache(options, toolctlAPI, baseURL)
+	if err != nil {
+		return
+	}
+	return NewIndexedAPI(toolctlAPI), nil
+}
@@ -0,0 +1,141 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// cacheEntry is a response of the remote API, as stored in the cache.
+type cacheEntry struct {
+	Found        bool      `json:"found"`
+	Contents     []byte    `json:"contents,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+// revalidator is implemented by APIs that support conditional requests.
+type revalidator interface {
+	getContentsIfChanged(path string, cached *cacheEntry) (entry cacheEntry, notModified bool, err error)
+}
+
+// cachedAPI wraps another API and caches its responses on disk. Cached
+// responses are used as long as they are younger than the TTL. After that,
+// they are revalidated with a conditional request if the wrapped API supports
+// it, or fetched again otherwise. Errors are never cached.
+type cachedAPI struct {
+	ToolctlAPI
+	cacheFS  afero.Fs
+	cacheDir string
+	ttl      time.Duration
+	// staleBefore is set to the creation time of the API when refreshing, so
+	// that every response is revalidated once.
+	staleBefore time.Time
+}
+
+// NewCachedAPI returns an API that caches the responses of the specified API
+// in a directory. If refresh is true, all cached responses are revalidated.
+func NewCachedAPI(
+	toolctlAPI ToolctlAPI, cacheFS afero.Fs, cacheDir string, ttl time.Duration, refresh bool,
+) ToolctlAPI {
+	a := &cachedAPI{
+		ToolctlAPI: toolctlAPI,
+		cacheFS:    cacheFS,
+		cacheDir:   cacheDir,
+		ttl:        ttl,
+	}
+	if refresh {
+		a.staleBefore = time.Now()
+	}
+	return a
+}
+
+func (a *cachedAPI) GetContents(path string) (found bool, contents []byte, err error) {
+	cached, isCached := a.load(path)
+	staleBefore := time.Now().Add(-a.ttl)
+	if a.staleBefore.After(staleBefore) {
+		staleBefore = a.staleBefore
+	}
+	if isCached && cached.FetchedAt.After(staleBefore) {
+		return cached.Found, cached.Contents, nil
+	}
+
+	var entry cacheEntry
+	if r, ok := a.ToolctlAPI.(revalidator); ok {
+		var previous *cacheEntry
+		if isCached {
+			previous = &cached
+		}
+		var notModified bool
+		entry, notModified, err = r.getContentsIfChanged(path, previous)
+		if err != nil {
+			return
+		}
+		if notModified {
+			cached.FetchedAt = entry.FetchedAt
+			entry = cached
+		}
+	} else {
+		entry.Found, entry.Contents, err = a.ToolctlAPI.GetContents(path)
+		if err != nil {
+			return
+		}
+		entry.FetchedAt = time.Now()
+	}
+
+	// The cache is only an optimization, so failing to write it is not an error
+	a.save(path, entry)
+
+	return entry.Found, entry.Contents, nil
+}
+
+// SaveContents saves the contents to the wrapped API, and drops them from the
+// cache.
+func (a *cachedAPI) SaveContents(path string, contents []byte) (err error) {
+	err = a.ToolctlAPI.SaveContents(path, contents)
+	if err != nil {
+		return
+	}
+	_ = a.cacheFS.Remove(a.cachePath(path))
+	return
+}
+
+// cachePath returns the path of the cache file for a path of the API.
+func (a *cachedAPI) cachePath(path string) string {
+	return filepath.Join(a.cacheDir, filepath.FromSlash(path)+".json")
+}
+
+// CacheDir returns the directory in which the responses of the API with the
+// specified base URL are cached. The cache is partitioned by the base URL, so
+// that different APIs don't share their responses.
+func CacheDir(cacheBaseDir string, baseURL string) string {
+	partition := sha256.Sum256([]byte(baseURL))
+	return filepath.Join(cacheBaseDir, "api", hex.EncodeToString(partition[:8]))
+}
+
+func (a *cachedAPI) load(path string) (entry cacheEntry, ok bool) {
+	contents, err := afero.ReadFile(a.cacheFS, a.cachePath(path))
+	if err != nil {
+		return
+	}
+	ok = json.Unmarshal(contents, &entry) == nil
+	return
+}
+
+func (a *cachedAPI) save(path string, entry cacheEntry) {
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	cachePath := a.cachePath(path)
+	if a.cacheFS.MkdirAll(filepath.Dir(cachePath), 0755) != nil {
+		return
+	}
+	_ = afero.WriteFile(a.cacheFS, cachePath, contents, 0644)
+}
@@ -0,0 +1,126 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func Test_cachedAPI_GetContents(t *testing.T) {
+	tests := []struct {
+		name              string
+		ttl               time.Duration
+		refresh           bool
+		path              string
+		runs              int
+		wantFound         bool
+		wantErr           bool
+		wantRequests      int
+		wantRevalidations int
+	}{
+		{
+			name:         "fresh responses are served from the cache",
+			ttl:          time.Hour,
+			path:         "/meta.yaml",
+			runs:         2,
+			wantFound:    true,
+			wantRequests: 1,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:              "stale responses are revalidated",
+			path:              "/meta.yaml",
+			runs:              2,
+			wantFound:         true,
+			wantRequests:      4,
+			wantRevalidations: 3,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:              "refresh revalidates fresh responses once",
+			ttl:               time.Hour,
+			refresh:           true,
+			path:              "/meta.yaml",
+			runs:              2,
+			wantFound:         true,
+			wantRequests:      2,
+			wantRevalidations: 1,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:         "not found responses are cached too",
+			ttl:          time.Hour,
+			path:         "/unknown/meta.yaml",
+			runs:         2,
+			wantRequests: 1,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:         "error responses are not cached",
+			ttl:          time.Hour,
+			path:         "/unavailable/meta.yaml",
+			runs:         2,
+			wantErr:      true,
+			wantRequests: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests, revalidations int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.URL.Path == "/unavailable/meta.yaml" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				if r.URL.Path != "/meta.yaml" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					revalidations++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				_, _ = w.Write([]byte("tools: []\n"))
+			}))
+			defer server.Close()
+
+			cacheFS := afero.NewMemMapFs()
+			remote, err := NewRemoteAPI(cacheFS, server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for run := 0; run < tt.runs; run++ {
+				// Every run is a new invocation of toolctl, with its own API
+				refresh := tt.refresh && run == tt.runs-1
+				a := NewCachedAPI(remote, cacheFS, CacheDir("/cache", server.URL), tt.ttl, refresh)
+				for i := 0; i < 2; i++ {
+					found, contents, err := a.GetContents(tt.path)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("GetContents() error = %v, wantErr %v", err, tt.wantErr)
+					}
+					if found != tt.wantFound {
+						t.Errorf("GetContents() found = %v, want %v", found, tt.wantFound)
+					}
+					if found && string(contents) != "tools: []\n" {
+						t.Errorf("GetContents() contents = %q, want %q", contents, "tools: []\n")
+					}
+				}
+			}
+
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %v, want %v", requests, tt.wantRequests)
+			}
+			if revalidations != tt.wantRevalidations {
+				t.Errorf("revalidations = %v, want %v", revalidations, tt.wantRevalidations)
+			}
+		})
+	}
+}
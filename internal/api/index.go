@@ -0,0 +1,264 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// IndexPath is the path of the index, which holds all metadata of the API in
+// a single file, so that clients need one request instead of dozens.
+const IndexPath = "index.json"
+
+// Index contains the metadata of all tools, platforms and versions.
+type Index struct {
+	Tools map[string]IndexTool `json:"tools"`
+}
+
+// IndexTool contains the metadata of a tool and all of its platforms.
+type IndexTool struct {
+	Meta      ToolMeta                 `json:"meta"`
+	Platforms map[string]IndexPlatform `json:"platforms,omitempty"`
+}
+
+// IndexPlatform contains the metadata of a tool platform and all of its
+// versions.
+type IndexPlatform struct {
+	Meta     ToolPlatformMeta                   `json:"meta"`
+	Versions map[string]ToolPlatformVersionMeta `json:"versions,omitempty"`
+}
+
+// BuildIndex builds the index from the files of a local API. Other registries
+// can't be listed, so they are rejected.
+func BuildIndex(toolctlAPI ToolctlAPI) (index Index, err error) {
+	if toolctlAPI.LocalAPIBasePath() == "" {
+		err = fmt.Errorf("the index can only be built from a local registry")
+		return
+	}
+
+	meta, err := GetMeta(toolctlAPI)
+	if err != nil {
+		return
+	}
+
+	index.Tools = map[string]IndexTool{}
+	for _, toolName := range meta.Tools {
+		tool := Tool{Name: toolName}
+
+		var indexTool IndexTool
+		indexTool.Meta, err = GetToolMeta(toolctlAPI, tool)
+		if err != nil {
+			return
+		}
+
+		indexTool.Platforms, err = buildIndexPlatforms(toolctlAPI, tool)
+		if err != nil {
+			return
+		}
+
+		index.Tools[toolName] = indexTool
+	}
+
+	return
+}
+
+// buildIndexPlatforms collects the metadata of all platforms of a tool.
+func buildIndexPlatforms(
+	toolctlAPI ToolctlAPI, tool Tool,
+) (platforms map[string]IndexPlatform, err error) {
+	platformDirs, err := afero.ReadDir(
+		toolctlAPI.LocalAPIFS(), filepath.Join(toolctlAPI.LocalAPIBasePath(), tool.Name),
+	)
+	if err != nil {
+		return
+	}
+
+	platforms = map[string]IndexPlatform{}
+	for _, platformDir := range platformDirs {
+		var found bool
+		tool.OS, tool.Arch, found = strings.Cut(platformDir.Name(), "-")
+		if !platformDir.IsDir() || !found {
+			continue
+		}
+
+		var platform IndexPlatform
+		platform.Meta, err = GetToolPlatformMeta(toolctlAPI, tool)
+		if err != nil {
+			return
+		}
+
+		var versions []string
+		versions, err = listLocalVersions(toolctlAPI, tool)
+		if err != nil {
+			return
+		}
+
+		platform.Versions = map[string]ToolPlatformVersionMeta{}
+		for _, version := range versions {
+			tool.Version = version
+			platform.Versions[tool.Version], err = GetToolPlatformVersionMeta(toolctlAPI, tool)
+			if err != nil {
+				return
+			}
+		}
+
+		platforms[platformDir.Name()] = platform
+	}
+
+	return
+}
+
+// SaveIndex saves the index to the API.
+func SaveIndex(toolctlAPI ToolctlAPI, index Index) (err error) {
+	contents, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+	err = toolctlAPI.SaveContents(IndexPath, contents)
+	return
+}
+
+// UpdateIndex updates the metadata of a tool version in the index of the API,
+// if it has one, so that versions that are added or yanked after api sync are
+// visible to clients right away. Tools that aren't in the index yet are left
+// to api sync, which also adds them to the list of tools.
+func UpdateIndex(toolctlAPI ToolctlAPI, tool Tool) (err error) {
+	found, contents, err := toolctlAPI.GetContents(IndexPath)
+	if err != nil || !found {
+		return
+	}
+	var index Index
+	err = json.Unmarshal(contents, &index)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", IndexPath, err)
+	}
+
+	indexTool, ok := index.Tools[tool.Name]
+	if !ok {
+		return
+	}
+	indexTool.Meta, err = GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	platformName := tool.OS + "-" + tool.Arch
+	platform := indexTool.Platforms[platformName]
+	platform.Meta, err = GetToolPlatformMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+	if platform.Versions == nil {
+		platform.Versions = map[string]ToolPlatformVersionMeta{}
+	}
+	platform.Versions[tool.Version], err = GetToolPlatformVersionMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	if indexTool.Platforms == nil {
+		indexTool.Platforms = map[string]IndexPlatform{}
+	}
+	indexTool.Platforms[platformName] = platform
+	index.Tools[tool.Name] = indexTool
+	return SaveIndex(toolctlAPI, index)
+}
+
+// contents returns the contents of a path of the API, rendered from the index.
+// If the path isn't covered by the index, handled is false.
+func (i Index) contents(path string) (handled bool, found bool, contents []byte, err error) {
+	var meta any
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "meta.yaml":
+		tools := make([]string, 0, len(i.Tools))
+		for toolName := range i.Tools {
+			tools = append(tools, toolName)
+		}
+		slices.Sort(tools)
+		meta = Meta{Tools: tools}
+	case len(parts) == 2 && parts[1] == "meta.yaml":
+		if indexTool, ok := i.Tools[parts[0]]; ok {
+			meta = indexTool.Meta
+		}
+	case len(parts) == 3 && strings.HasSuffix(parts[2], ".yaml"):
+		platform, ok := i.Tools[parts[0]].Platforms[parts[1]]
+		if !ok {
+			break
+		}
+		if parts[2] == "meta.yaml" {
+			meta = platform.Meta
+		} else if version, ok := platform.Versions[strings.TrimSuffix(parts[2], ".yaml")]; ok {
+			meta = version
+		}
+	default:
+		return
+	}
+	handled = true
+
+	if meta == nil {
+		return
+	}
+	found = true
+	contents, err = yaml.Marshal(meta)
+	return
+}
+
+// indexedAPI wraps another API and serves the metadata from the index of the
+// API, if it has one. Other paths, and all paths of APIs without an index, are
+// passed through.
+type indexedAPI struct {
+	ToolctlAPI
+	loaded bool
+	index  *Index
+}
+
+// NewIndexedAPI returns an API that prefers the index of the specified API.
+func NewIndexedAPI(toolctlAPI ToolctlAPI) ToolctlAPI {
+	return &indexedAPI{ToolctlAPI: toolctlAPI}
+}
+
+func (a *indexedAPI) GetContents(path string) (found bool, contents []byte, err error) {
+	if !a.loaded {
+		err = a.load()
+		if err != nil {
+			return
+		}
+	}
+
+	if a.index != nil {
+		var handled bool
+		handled, found, contents, err = a.index.contents(path)
+		if handled {
+			return
+		}
+	}
+
+	return a.ToolctlAPI.GetContents(path)
+}
+
+// load fetches the index once. APIs without an index are not an error.
+func (a *indexedAPI) load() (err error) {
+	found, contents, err := a.ToolctlAPI.GetContents(IndexPath)
+	if err != nil {
+		return
+	}
+	a.loaded = true
+	if !found {
+		return
+	}
+
+	var index Index
+	err = json.Unmarshal(contents, &index)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", IndexPath, err)
+	}
+	a.index = &index
+	return
+}
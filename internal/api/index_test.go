@@ -0,0 +1,219 @@
+package api_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+func TestIndexedAPI(t *testing.T) {
+	localAPI, _, err := setupTest(localAPILocation, apiContents{
+		{
+			Path:     path.Join(localAPIBasePath, "meta.yaml"),
+			Contents: "tools:\n  - toolctl-test-tool\n",
+		},
+		{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool", "meta.yaml"),
+			Contents: `description: toolctl test tool
+downloadURLTemplate: https://example.com/{{.Version}}.tar.gz
+versionArgs: [version]
+`,
+		},
+		{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool", "linux-amd64", "meta.yaml"),
+			Contents: `version:
+  earliest: 0.1.0
+  latest: 0.1.1
+`,
+		},
+		{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool", "linux-amd64", "0.1.1.yaml"),
+			Contents: `url: https://example.com/0.1.1.tar.gz
+sha256: 0123456789abcdef
+releaseDate: 2024-03-15T00:00:00Z
+`,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := api.BuildIndex(localAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An API that only contains the index, so everything has to come from it
+	indexOnlyAPI, err := api.NewLocalAPI(afero.NewMemMapFs(), localAPIBasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = api.SaveIndex(indexOnlyAPI, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexedAPI := api.NewIndexedAPI(indexOnlyAPI)
+
+	tool := api.Tool{Name: "toolctl-test-tool", OS: "linux", Arch: "amd64", Version: "0.1.1"}
+
+	meta, err := api.GetMeta(indexedAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(api.Meta{Tools: []string{"toolctl-test-tool"}}, meta); diff != "" {
+		t.Errorf("GetMeta() mismatch (-want +got):\n%s", diff)
+	}
+
+	toolMeta, err := api.GetToolMeta(indexedAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(api.ToolMeta{
+		Description:         "toolctl test tool",
+		DownloadURLTemplate: "https://example.com/{{.Version}}.tar.gz",
+		VersionArgs:         []string{"version"},
+	}, toolMeta, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("GetToolMeta() mismatch (-want +got):\n%s", diff)
+	}
+
+	toolPlatformMeta, err := api.GetToolPlatformMeta(indexedAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(api.ToolPlatformMeta{
+		Version: api.ToolPlatformMetaVersion{Earliest: "0.1.0", Latest: "0.1.1"},
+	}, toolPlatformMeta); diff != "" {
+		t.Errorf("GetToolPlatformMeta() mismatch (-want +got):\n%s", diff)
+	}
+
+	toolPlatformVersionMeta, err := api.GetToolPlatformVersionMeta(indexedAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(api.ToolPlatformVersionMeta{
+		URL:         "https://example.com/0.1.1.tar.gz",
+		SHA256:      "0123456789abcdef",
+		ReleaseDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+	}, toolPlatformVersionMeta); diff != "" {
+		t.Errorf("GetToolPlatformVersionMeta() mismatch (-want +got):\n%s", diff)
+	}
+
+	tool.Version = "0.1.0"
+	_, err = api.GetToolPlatformVersionMeta(indexedAPI, tool)
+	if !errors.Is(err, api.NotFoundError{}) {
+		t.Errorf("GetToolPlatformVersionMeta() error = %v, want %v", err, api.NotFoundError{})
+	}
+
+	// APIs without an index are passed through
+	meta, err = api.GetMeta(api.NewIndexedAPI(localAPI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(api.Meta{Tools: []string{"toolctl-test-tool"}}, meta); diff != "" {
+		t.Errorf("GetMeta() without index mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildIndexRemoteAPI(t *testing.T) {
+	remoteAPI, err := api.NewRemoteAPI(afero.NewMemMapFs(), "https://example.com/toolctl/api/v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = api.BuildIndex(remoteAPI)
+	wantErrStr := "the index can only be built from a local registry"
+	if err == nil || err.Error() != wantErrStr {
+		t.Errorf("BuildIndex() error = %v, want %v", err, wantErrStr)
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	localAPI, _, err := setupTest(localAPILocation, apiContents{
+		{
+			Path:     path.Join(localAPIBasePath, "meta.yaml"),
+			Contents: "tools:\n  - toolctl-test-tool\n",
+		},
+		{
+			Path:     path.Join(localAPIBasePath, "toolctl-test-tool", "meta.yaml"),
+			Contents: "description: toolctl test tool\n",
+		},
+		{
+			Path:     path.Join(localAPIBasePath, "toolctl-test-tool", "linux-amd64", "meta.yaml"),
+			Contents: "version:\n  earliest: 0.1.0\n  latest: 0.1.0\n",
+		},
+		{
+			Path:     path.Join(localAPIBasePath, "toolctl-test-tool", "linux-amd64", "0.1.0.yaml"),
+			Contents: "url: https://example.com/0.1.0.tar.gz\nsha256: 0123456789abcdef\n",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// APIs without an index are left alone
+	tool := api.Tool{Name: "toolctl-test-tool", OS: "linux", Arch: "amd64", Version: "0.1.0"}
+	err = api.UpdateIndex(localAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, _, err := localAPI.GetContents(api.IndexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatalf("UpdateIndex() created an index")
+	}
+
+	index, err := api.BuildIndex(localAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = api.SaveIndex(localAPI, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a version after the index was built, like api discover does
+	tool.Version = "0.1.1"
+	err = api.SaveToolPlatformVersionMeta(localAPI, tool, api.ToolPlatformVersionMeta{
+		URL: "https://example.com/0.1.1.tar.gz", SHA256: "fedcba9876543210",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = api.SaveToolPlatformMeta(localAPI, tool, api.ToolPlatformMeta{
+		Version: api.ToolPlatformMetaVersion{Earliest: "0.1.0", Latest: "0.1.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = api.UpdateIndex(localAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexedAPI := api.NewIndexedAPI(localAPI)
+	toolPlatformMeta, err := api.GetToolPlatformMeta(indexedAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toolPlatformMeta.Version.Latest != "0.1.1" {
+		t.Errorf("GetToolPlatformMeta() latest = %s, want 0.1.1", toolPlatformMeta.Version.Latest)
+	}
+	toolPlatformVersionMeta, err := api.GetToolPlatformVersionMeta(indexedAPI, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toolPlatformVersionMeta.SHA256 != "fedcba9876543210" {
+		t.Errorf(
+			"GetToolPlatformVersionMeta() SHA256 = %s, want fedcba9876543210",
+			toolPlatformVersionMeta.SHA256,
+		)
+	}
+}
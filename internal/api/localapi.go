@@ -36,10 +36,6 @@ func (a localAPI) LocalAPIFS() afero.Fs {
 	return a.localAPIFS
 }
 
-func (a localAPI) Location() Location {
-	return Local
-}
-
 // Save writes the give contents to a file at the given path.
 func (a localAPI) SaveContents(relativePath string, contents []byte) (err error) {
 	absolutePath := path.Join(a.basePath, relativePath)
@@ -5,13 +5,14 @@ import (
 	"bytes"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Meta contains metadata for the toolctl API.
 type Meta struct {
-	Tools []string
+	Tools []string `json:"tools"`
 }
 
 // GetMeta returns the metadata for the toolctl API.
@@ -51,11 +52,24 @@ func SaveMeta(toolctlAPI ToolctlAPI, meta Meta) (err error) {
 
 // ToolMeta contains metadata for a tool.
 type ToolMeta struct {
-	Description         string
-	DownloadURLTemplate string `yaml:"downloadURLTemplate"`
-	Homepage            string
-	IgnoredVersions     []string `yaml:"ignoredVersions"`
-	VersionArgs         []string `yaml:"versionArgs"`
+	ChecksumURLTemplate string            `yaml:"checksumURLTemplate,omitempty" json:"checksumURLTemplate,omitempty"`
+	Description         string            `json:"description"`
+	DownloadURLTemplate string            `yaml:"downloadURLTemplate" json:"downloadURLTemplate"`
+	Homepage            string            `json:"homepage"`
+	IgnoredVersions     []string          `yaml:"ignoredVersions" json:"ignoredVersions"`
+	PrereleaseChannels  []string          `yaml:"prereleaseChannels,omitempty" json:"prereleaseChannels,omitempty"`
+	Provenance          *ProvenancePolicy `yaml:",omitempty" json:"provenance,omitempty"`
+	PublicKeys          map[string]string `yaml:"publicKeys,omitempty" json:"publicKeys,omitempty"`
+	VersionArgs         []string          `yaml:"versionArgs" json:"versionArgs"`
+	VersionRegex        string            `yaml:"versionRegex,omitempty" json:"versionRegex,omitempty"`
+	VersionScheme       string            `yaml:"versionScheme,omitempty" json:"versionScheme,omitempty"`
+	VersionStream       string            `yaml:"versionStream,omitempty" json:"versionStream,omitempty"`
+}
+
+// ProvenancePolicy lists the builders that are trusted to have built a tool.
+// Builder IDs without a version ("@ref") match all versions of a builder.
+type ProvenancePolicy struct {
+	BuilderIDs []string `yaml:"builderIDs" json:"builderIDs"`
 }
 
 // GetToolMeta returns the metadata for the given tool.
@@ -77,13 +91,16 @@ func GetToolMeta(toolctlAPI ToolctlAPI, tool Tool) (meta ToolMeta, err error) {
 
 // ToolPlatformMeta contains metadata for a given tool and platform.
 type ToolPlatformMeta struct {
-	Version ToolPlatformMetaVersion
+	Version ToolPlatformMetaVersion `json:"version"`
 }
 
 // ToolPlatformMetaVersion contains version metadata for a given tool and platform.
+// Earliest and Latest only consider stable versions, the latest prerelease of
+// each release channel is tracked in Channels.
 type ToolPlatformMetaVersion struct {
-	Earliest string
-	Latest   string
+	Earliest string            `json:"earliest"`
+	Latest   string            `json:"latest"`
+	Channels map[string]string `yaml:",omitempty" json:"channels,omitempty"`
 }
 
 // GetToolPlatformMeta returns the metadata for the given tool and platform.
@@ -125,9 +142,40 @@ func SaveToolPlatformMeta(toolctlAPI ToolctlAPI, tool Tool, meta ToolPlatformMet
 }
 
 // ToolPlatformVersionMeta contains metadata for a given tool version and platform.
+// Mirrors are fallback URLs of the same download, tried in order if URL fails.
+// Digests maps additional digest algorithms (sha256, sha512, blake2b) to the
+// expected digests of the download. BinarySHA256 is the SHA256 of the extracted
+// binary, which makes it possible to verify installed binaries.
 type ToolPlatformVersionMeta struct {
-	URL    string
-	SHA256 string
+	URL          string            `json:"url"`
+	Mirrors      []string          `yaml:",omitempty" json:"mirrors,omitempty"`
+	SHA256       string            `json:"sha256"`
+	Digests      map[string]string `yaml:",omitempty" json:"digests,omitempty"`
+	BinarySHA256 string            `yaml:"binarySHA256,omitempty" json:"binarySHA256,omitempty"`
+	Signature    *SignatureMeta    `yaml:",omitempty" json:"signature,omitempty"`
+	Provenance   *ProvenanceMeta   `yaml:",omitempty" json:"provenance,omitempty"`
+	Channel      string            `yaml:",omitempty" json:"channel,omitempty"`
+	ReleaseDate  time.Time         `yaml:"releaseDate,omitempty" json:"releaseDate,omitzero"`
+	Yanked       bool              `yaml:",omitempty" json:"yanked,omitempty"`
+	YankedReason string            `yaml:"yankedReason,omitempty" json:"yankedReason,omitempty"`
+}
+
+// SignatureMeta contains the metadata needed to verify the signature of a
+// downloaded tool. Key refers to a public key pinned in the user config or
+// in the tool metadata.
+type SignatureMeta struct {
+	URL  string `json:"url"`
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+// ProvenanceMeta contains the metadata needed to verify the SLSA provenance
+// of a downloaded tool. The attestation has to be signed with the public key
+// named by Key. Keyless attestations, signed with a Fulcio certificate, are
+// not supported.
+type ProvenanceMeta struct {
+	URL string `json:"url"`
+	Key string `yaml:",omitempty" json:"key,omitempty"`
 }
 
 // GetToolPlatformVersionMeta returns the metadata for the given tool version and platform.
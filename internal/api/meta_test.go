@@ -47,7 +47,7 @@ func TestGetMeta(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		for _, apiLocation := range []api.Location{api.Remote, api.Local} {
+		for _, apiLocation := range []apiLocation{remoteAPILocation, localAPILocation} {
 			toolctlAPI, apiServer, err := setupTest(apiLocation, tt.apiContents)
 			if err != nil {
 				t.Fatal(err)
@@ -63,7 +63,7 @@ func TestGetMeta(t *testing.T) {
 				}
 			})
 
-			if apiLocation == api.Remote {
+			if apiLocation == remoteAPILocation {
 				apiServer.Close()
 			}
 		}
@@ -77,14 +77,14 @@ func TestSaveMeta(t *testing.T) {
 	}
 	tests := []struct {
 		name            string
-		apiLocation     api.Location
+		apiLocation     apiLocation
 		args            args
 		wantAPIContents apiContents
 		wantErrStr      string
 	}{
 		{
 			name:        "save with local API",
-			apiLocation: api.Local,
+			apiLocation: localAPILocation,
 			args: args{
 				meta: api.Meta{
 					Tools: []string{
@@ -105,7 +105,7 @@ func TestSaveMeta(t *testing.T) {
 		},
 		{
 			name:        "save with remote API",
-			apiLocation: api.Remote,
+			apiLocation: remoteAPILocation,
 			args: args{
 				meta: api.Meta{
 					Tools: []string{
@@ -134,7 +134,7 @@ func TestSaveMeta(t *testing.T) {
 			}
 		})
 
-		if tt.apiLocation == api.Remote {
+		if tt.apiLocation == remoteAPILocation {
 			apiServer.Close()
 		}
 	}
@@ -189,7 +189,7 @@ func TestGetToolMeta(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		for _, apiLocation := range []api.Location{api.Remote, api.Local} {
+		for _, apiLocation := range []apiLocation{remoteAPILocation, localAPILocation} {
 			toolctlAPI, apiServer, err := setupTest(apiLocation, tt.apiContents)
 			if err != nil {
 				t.Fatal(err)
@@ -205,7 +205,7 @@ func TestGetToolMeta(t *testing.T) {
 				}
 			})
 
-			if apiLocation == api.Remote {
+			if apiLocation == remoteAPILocation {
 				apiServer.Close()
 			}
 		}
@@ -264,7 +264,7 @@ func TestGetToolPlatformMeta(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		for _, apiLocation := range []api.Location{api.Remote, api.Local} {
+		for _, apiLocation := range []apiLocation{remoteAPILocation, localAPILocation} {
 			toolctlAPI, apiServer, err := setupTest(apiLocation, tt.apiContents)
 			if err != nil {
 				t.Fatal(err)
@@ -281,7 +281,7 @@ func TestGetToolPlatformMeta(t *testing.T) {
 				}
 			})
 
-			if apiLocation == api.Remote {
+			if apiLocation == remoteAPILocation {
 				apiServer.Close()
 			}
 		}
@@ -296,14 +296,14 @@ func TestSaveToolPlatformMeta(t *testing.T) {
 	}
 	tests := []struct {
 		name            string
-		apiLocation     api.Location
+		apiLocation     apiLocation
 		args            args
 		wantAPIContents apiContents
 		wantErrStr      string
 	}{
 		{
 			name:        "save with local API",
-			apiLocation: api.Local,
+			apiLocation: localAPILocation,
 			args: args{
 				tool: api.Tool{
 					Name: "toolctl-test-tool",
@@ -328,7 +328,7 @@ latest: 1.3.2
 		},
 		{
 			name:        "save with remote API",
-			apiLocation: api.Remote,
+			apiLocation: remoteAPILocation,
 			args: args{
 				tool: api.Tool{
 					Name: "toolctl-test-tool",
@@ -363,7 +363,7 @@ latest: 1.3.2
 			}
 		})
 
-		if tt.apiLocation == api.Remote {
+		if tt.apiLocation == remoteAPILocation {
 			apiServer.Close()
 		}
 	}
@@ -405,6 +405,68 @@ sha256: cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24
 				SHA256: "cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24",
 			},
 		},
+		{
+			name: "supported tool with signature",
+			apiContents: apiContents{
+				{
+					Path: path.Join(localAPIBasePath, "toolctl-test-tool/darwin-amd64/1.0.0.yaml"),
+					Contents: `
+url: https://localhost/release/v1.0.0/bin/darwin/amd64/toolctl-test-tool
+sha256: cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24
+signature:
+  url: https://localhost/release/v1.0.0/bin/darwin/amd64/toolctl-test-tool.minisig
+  key: toolctl-test-key
+  type: minisign
+`,
+				},
+			},
+			args: args{
+				tool: api.Tool{
+					Name:    "toolctl-test-tool",
+					OS:      "darwin",
+					Arch:    "amd64",
+					Version: "1.0.0",
+				},
+			},
+			wantPlatformVersion: api.ToolPlatformVersionMeta{
+				URL:    "https://localhost/release/v1.0.0/bin/darwin/amd64/toolctl-test-tool",
+				SHA256: "cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24",
+				Signature: &api.SignatureMeta{
+					URL:  "https://localhost/release/v1.0.0/bin/darwin/amd64/toolctl-test-tool.minisig",
+					Key:  "toolctl-test-key",
+					Type: "minisign",
+				},
+			},
+		},
+		{
+			name: "supported tool with provenance",
+			apiContents: apiContents{
+				{
+					Path: path.Join(localAPIBasePath, "toolctl-test-tool/darwin-amd64/1.0.0.yaml"),
+					Contents: `
+url: https://localhost/release/v1.0.0/bin/darwin/amd64/toolctl-test-tool
+sha256: cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24
+provenance:
+  url: https://localhost/release/v1.0.0/multiple.intoto.jsonl
+`,
+				},
+			},
+			args: args{
+				tool: api.Tool{
+					Name:    "toolctl-test-tool",
+					OS:      "darwin",
+					Arch:    "amd64",
+					Version: "1.0.0",
+				},
+			},
+			wantPlatformVersion: api.ToolPlatformVersionMeta{
+				URL:    "https://localhost/release/v1.0.0/bin/darwin/amd64/toolctl-test-tool",
+				SHA256: "cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24",
+				Provenance: &api.ProvenanceMeta{
+					URL: "https://localhost/release/v1.0.0/multiple.intoto.jsonl",
+				},
+			},
+		},
 		{
 			name:        "unsupported version",
 			apiContents: apiContents{},
@@ -433,7 +495,7 @@ sha256: cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24
 		},
 	}
 	for _, tt := range tests {
-		for _, apiLocation := range []api.Location{api.Remote, api.Local} {
+		for _, apiLocation := range []apiLocation{remoteAPILocation, localAPILocation} {
 			toolctlAPI, apiServer, err := setupTest(apiLocation, tt.apiContents)
 			if err != nil {
 				t.Fatal(err)
@@ -452,7 +514,7 @@ sha256: cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24
 				}
 			})
 
-			if apiLocation == api.Remote {
+			if apiLocation == remoteAPILocation {
 				apiServer.Close()
 			}
 		}
@@ -468,13 +530,13 @@ func TestSaveToolPlatformVersionMeta(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		apiLocation api.Location
+		apiLocation apiLocation
 		args        args
 		wantErr     bool
 	}{
 		{
 			name:        "local",
-			apiLocation: api.Local,
+			apiLocation: localAPILocation,
 			args: args{
 				tool: api.Tool{
 					Name: "toolctl-test-tool",
@@ -489,7 +551,7 @@ func TestSaveToolPlatformVersionMeta(t *testing.T) {
 		},
 		{
 			name:        "remote",
-			apiLocation: api.Remote,
+			apiLocation: remoteAPILocation,
 			wantErr:     true,
 		},
 	}
@@ -506,7 +568,7 @@ func TestSaveToolPlatformVersionMeta(t *testing.T) {
 			}
 		})
 
-		if tt.apiLocation == api.Remote {
+		if tt.apiLocation == remoteAPILocation {
 			apiServer.Close()
 		}
 	}
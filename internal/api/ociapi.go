@@ -0,0 +1,131 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/oci"
+)
+
+const (
+	// OCIArtifactType is the artifact type of APIs stored in OCI registries.
+	OCIArtifactType = "application/vnd.toolctl.api.v0"
+	// OCIFileMediaType is the media type of the files of the API, which are
+	// stored as the layers of the artifact, named by their path.
+	OCIFileMediaType = "application/vnd.toolctl.api.file.v0"
+)
+
+// ociAPI is an API that is stored as an artifact in an OCI registry. Each file
+// of the API is a layer of the artifact, so a single manifest request tells
+// which files exist, and the files themselves are fetched by digest.
+type ociAPI struct {
+	client     *oci.Client
+	ref        oci.Reference
+	localAPIFS afero.Fs
+
+	loaded   bool
+	manifest oci.Manifest
+}
+
+// NewOCIAPI returns an API that is stored in an OCI registry, e.g. at
+// oci://registry.example.com/toolctl/api:v0.
+func NewOCIAPI(localAPIFS afero.Fs, reference string) (ToolctlAPI, error) {
+	ref, err := oci.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	return &ociAPI{client: oci.NewClient(ref), ref: ref, localAPIFS: localAPIFS}, nil
+}
+
+// newOCIBackend returns an OCI API for oci:// and oci+http:// URLs.
+func newOCIBackend(registryURL *url.URL, options BackendOptions) (ToolctlAPI, error) {
+	return NewOCIAPI(options.LocalAPIFS, registryURL.String())
+}
+
+func (a *ociAPI) GetContents(path string) (found bool, contents []byte, err error) {
+	err = a.load()
+	if err != nil {
+		return
+	}
+
+	i := a.layerIndex(path)
+	if i < 0 {
+		return
+	}
+	contents, err = a.client.GetBlob(a.manifest.Layers[i].Digest)
+	if err != nil {
+		err = fmt.Errorf("failed to get %s: %w", path, err)
+		return
+	}
+	found = true
+	return
+}
+
+// SaveContents pushes the contents as a layer, replacing the layer with the
+// same path, and pushes the updated manifest.
+func (a *ociAPI) SaveContents(path string, contents []byte) (err error) {
+	if a.ref.Digest != "" {
+		return fmt.Errorf("cannot save to a registry that is pinned to a digest")
+	}
+	err = a.load()
+	if err != nil {
+		return
+	}
+
+	layer, err := a.client.PushBlob(OCIFileMediaType, contents)
+	if err != nil {
+		return
+	}
+	layer.Annotations = map[string]string{oci.AnnotationTitle: path}
+
+	manifest := a.manifest
+	manifest.Layers = slices.Clone(manifest.Layers)
+	if i := a.layerIndex(path); i >= 0 {
+		manifest.Layers[i] = layer
+	} else {
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+
+	err = a.client.PutManifest(a.ref.Reference(), manifest)
+	if err != nil {
+		return
+	}
+	a.manifest = manifest
+	return
+}
+
+// load fetches the manifest once. A missing manifest is an empty API.
+func (a *ociAPI) load() (err error) {
+	if a.loaded {
+		return
+	}
+
+	found, manifest, err := a.client.GetManifest(a.ref.Reference())
+	if err != nil {
+		return
+	}
+	if !found {
+		manifest = oci.NewManifest(OCIArtifactType)
+	}
+	a.manifest = manifest
+	a.loaded = true
+	return
+}
+
+// layerIndex returns the index of the layer of a path, or -1.
+func (a *ociAPI) layerIndex(path string) int {
+	return slices.IndexFunc(a.manifest.Layers, func(layer oci.Descriptor) bool {
+		return layer.Annotations[oci.AnnotationTitle] == path
+	})
+}
+
+func (a *ociAPI) LocalAPIBasePath() string {
+	return ""
+}
+
+func (a *ociAPI) LocalAPIFS() afero.Fs {
+	return a.localAPIFS
+}
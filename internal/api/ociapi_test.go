@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/oci/ocitest"
+)
+
+func TestOCIAPI(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	registry.RequireToken = true
+	defer registry.Close()
+
+	reference := registry.Reference("toolctl/api:v0")
+	writableAPI, err := api.Open(reference, api.BackendOptions{LocalAPIFS: afero.NewMemMapFs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The repository is empty before anything is saved
+	_, err = api.GetMeta(writableAPI)
+	if !errors.Is(err, api.NotFoundError{}) {
+		t.Fatalf("GetMeta() error = %v, want %v", err, api.NotFoundError{})
+	}
+
+	err = api.SaveMeta(writableAPI, api.Meta{Tools: []string{"toolctl-test-tool"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = api.SaveMeta(writableAPI, api.Meta{Tools: []string{"toolctl-other-test-tool", "toolctl-test-tool"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writableAPI.SaveContents("toolctl-test-tool/meta.yaml", []byte("description: toolctl test tool\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh API sees the saved files, with the later meta.yaml replacing the
+	// earlier one
+	toolctlAPI, err := api.Open(reference, api.BackendOptions{LocalAPIFS: afero.NewMemMapFs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := api.GetMeta(toolctlAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMeta := api.Meta{Tools: []string{"toolctl-other-test-tool", "toolctl-test-tool"}}
+	if diff := cmp.Diff(wantMeta, meta); diff != "" {
+		t.Errorf("GetMeta() mismatch (-want +got):\n%s", diff)
+	}
+
+	toolMeta, err := api.GetToolMeta(toolctlAPI, api.Tool{Name: "toolctl-test-tool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toolMeta.Description != "toolctl test tool" {
+		t.Errorf("GetToolMeta() description = %v, want %v", toolMeta.Description, "toolctl test tool")
+	}
+
+	found, _, err := toolctlAPI.GetContents("toolctl-other-test-tool/meta.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("GetContents() found a file that was never saved")
+	}
+}
+
+func TestOCIAPIPinnedToDigest(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	toolctlAPI, err := api.Open(
+		registry.Reference("toolctl/api@sha256:"+
+			"0000000000000000000000000000000000000000000000000000000000000000"),
+		api.BackendOptions{LocalAPIFS: afero.NewMemMapFs()},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = toolctlAPI.SaveContents("meta.yaml", []byte("tools: []\n"))
+	wantErr := "cannot save to a registry that is pinned to a digest"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("SaveContents() error = %v, want %v", err, wantErr)
+	}
+}
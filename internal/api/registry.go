@@ -0,0 +1,160 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryConfig configures a registry, i.e. an API that tools are looked up
+// in. Registries are either remote, with a URL, or local, with a path.
+type RegistryConfig struct {
+	Name string
+	URL  string
+	Path string
+}
+
+// Registry is a named API.
+type Registry struct {
+	Name string
+	API  ToolctlAPI
+}
+
+// compositeAPI layers multiple registries on top of each other. Each tool is
+// looked up in the registries in order of priority, and all of its metadata
+// comes from the first registry that has it. The list of tools is the union
+// of the tools of all registries.
+type compositeAPI struct {
+	registries []Registry
+	// toolRegistries maps the names of tools that were already looked up to
+	// the index of the registry they were found in, or -1 if none has them.
+	toolRegistries map[string]int
+}
+
+// NewCompositeAPI returns an API that layers the specified registries, the
+// first one having the highest priority.
+func NewCompositeAPI(registries []Registry) ToolctlAPI {
+	return &compositeAPI{
+		registries:     registries,
+		toolRegistries: map[string]int{},
+	}
+}
+
+func (a *compositeAPI) GetContents(path string) (found bool, contents []byte, err error) {
+	if path == "meta.yaml" {
+		return a.getMetaContents()
+	}
+
+	toolName, _, isToolPath := strings.Cut(path, "/")
+	if !isToolPath {
+		// Files that don't belong to a tool come from the first registry that
+		// has them
+		for _, registry := range a.registries {
+			found, contents, err = registry.API.GetContents(path)
+			if err != nil || found {
+				return
+			}
+		}
+		return
+	}
+
+	registry, found, err := a.toolRegistry(toolName)
+	if err != nil || !found {
+		return
+	}
+	return registry.API.GetContents(path)
+}
+
+// getMetaContents merges the global metadata of all registries.
+func (a *compositeAPI) getMetaContents() (found bool, contents []byte, err error) {
+	var tools []string
+	for _, registry := range a.registries {
+		var meta Meta
+		meta, err = GetMeta(registry.API)
+		if err != nil {
+			if errors.Is(err, NotFoundError{}) {
+				err = nil
+				continue
+			}
+			return
+		}
+		found = true
+		for _, toolName := range meta.Tools {
+			if !slices.Contains(tools, toolName) {
+				tools = append(tools, toolName)
+			}
+		}
+	}
+	if !found {
+		return
+	}
+
+	slices.Sort(tools)
+	contents, err = yaml.Marshal(Meta{Tools: tools})
+	return
+}
+
+// toolRegistry returns the registry with the highest priority that has the
+// specified tool.
+func (a *compositeAPI) toolRegistry(toolName string) (registry Registry, found bool, err error) {
+	i, ok := a.toolRegistries[toolName]
+	if !ok {
+		i = -1
+		for j, r := range a.registries {
+			var hasTool bool
+			hasTool, _, err = r.API.GetContents(toolName + "/meta.yaml")
+			if err != nil {
+				return
+			}
+			if hasTool {
+				i = j
+				break
+			}
+		}
+		a.toolRegistries[toolName] = i
+	}
+
+	if i < 0 {
+		return
+	}
+	return a.registries[i], true, nil
+}
+
+func (a *compositeAPI) LocalAPIBasePath() string {
+	return ""
+}
+
+func (a *compositeAPI) LocalAPIFS() afero.Fs {
+	return a.registries[0].API.LocalAPIFS()
+}
+
+// SaveContents is not supported, because it's unclear which registry the
+// contents should be saved to.
+func (a *compositeAPI) SaveContents(_ string, _ []byte) error {
+	return fmt.Errorf("not implemented")
+}
+
+// GetToolRegistry returns the name of the registry that a tool comes from, or
+// an empty string if the API doesn't consist of multiple registries.
+func GetToolRegistry(toolctlAPI ToolctlAPI, tool Tool) (name string, err error) {
+	composite, ok := toolctlAPI.(*compositeAPI)
+	if !ok {
+		return
+	}
+
+	registry, found, err := composite.toolRegistry(tool.Name)
+	if err != nil {
+		return
+	}
+	if !found {
+		err = fmt.Errorf("%s %w", tool.Name, NotFoundError{})
+		return
+	}
+	name = registry.Name
+	return
+}
@@ -0,0 +1,134 @@
+package api_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+func TestCompositeAPI(t *testing.T) {
+	companyAPI, companyServer, err := setupTest(remoteAPILocation, apiContents{
+		{
+			Path:     path.Join(localAPIBasePath, "meta.yaml"),
+			Contents: "tools:\n  - toolctl-test-tool\n",
+		},
+		{
+			Path:     path.Join(localAPIBasePath, "toolctl-test-tool", "meta.yaml"),
+			Contents: "description: company build of the toolctl test tool\n",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer companyServer.Close()
+
+	publicAPI, publicServer, err := setupTest(remoteAPILocation, apiContents{
+		{
+			Path:     path.Join(localAPIBasePath, "meta.yaml"),
+			Contents: "tools:\n  - toolctl-other-test-tool\n  - toolctl-test-tool\n",
+		},
+		{
+			Path:     path.Join(localAPIBasePath, "toolctl-test-tool", "meta.yaml"),
+			Contents: "description: toolctl test tool\n",
+		},
+		{
+			Path:     path.Join(localAPIBasePath, "toolctl-other-test-tool", "meta.yaml"),
+			Contents: "description: toolctl other test tool\n",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publicServer.Close()
+
+	compositeAPI := api.NewCompositeAPI([]api.Registry{
+		{Name: "company", API: companyAPI},
+		{Name: "public", API: publicAPI},
+	})
+
+	meta, err := api.GetMeta(compositeAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMeta := api.Meta{Tools: []string{"toolctl-other-test-tool", "toolctl-test-tool"}}
+	if diff := cmp.Diff(wantMeta, meta); diff != "" {
+		t.Errorf("GetMeta() mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		toolName        string
+		wantDescription string
+		wantRegistry    string
+	}{
+		{
+			toolName:        "toolctl-test-tool",
+			wantDescription: "company build of the toolctl test tool",
+			wantRegistry:    "company",
+		},
+		{
+			toolName:        "toolctl-other-test-tool",
+			wantDescription: "toolctl other test tool",
+			wantRegistry:    "public",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.toolName, func(t *testing.T) {
+			tool := api.Tool{Name: tt.toolName}
+
+			toolMeta, err := api.GetToolMeta(compositeAPI, tool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if toolMeta.Description != tt.wantDescription {
+				t.Errorf("GetToolMeta() description = %v, want %v", toolMeta.Description, tt.wantDescription)
+			}
+
+			registry, err := api.GetToolRegistry(compositeAPI, tool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registry != tt.wantRegistry {
+				t.Errorf("GetToolRegistry() = %v, want %v", registry, tt.wantRegistry)
+			}
+		})
+	}
+}
+
+func TestNewWithInvalidRegistries(t *testing.T) {
+	tests := []struct {
+		name       string
+		registries []map[string]any
+		wantErrStr string
+	}{
+		{
+			name:       "registry without name",
+			registries: []map[string]any{{"url": "https://example.com/v0/"}},
+			wantErrStr: "invalid Registries: registry 1 has no name",
+		},
+		{
+			name: "registry with url and path",
+			registries: []map[string]any{
+				{"name": "company", "url": "https://example.com/v0/", "path": "/v0"},
+			},
+			wantErrStr: "invalid Registries: registry company needs either a url or a path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("local", false, "")
+			viper.Set("Registries", tt.registries)
+			defer viper.Set("Registries", nil)
+
+			_, err := api.New(afero.NewMemMapFs(), cmd)
+			if err == nil || err.Error() != tt.wantErrStr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+		})
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/httpclient"
 )
 
 type remoteAPI struct {
@@ -16,22 +18,63 @@ type remoteAPI struct {
 }
 
 func (a remoteAPI) GetContents(path string) (found bool, contents []byte, err error) {
+	entry, _, err := a.getContentsIfChanged(path, nil)
+	return entry.Found, entry.Contents, err
+}
+
+// getContentsIfChanged fetches the contents of a path. If a cached entry is
+// given, the request is conditional, and notModified is true if the contents
+// haven't changed since the entry was fetched. Only 404 responses mean that
+// the path doesn't exist, other unexpected responses are errors, so that a
+// temporary failure doesn't make tools look unsupported.
+func (a remoteAPI) getContentsIfChanged(
+	path string, cached *cacheEntry,
+) (entry cacheEntry, notModified bool, err error) {
+	req, err := http.NewRequest(
+		http.MethodGet, a.baseURL.ResolveReference(&url.URL{Path: path}).String(), nil,
+	)
+	if err != nil {
+		return
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
 	var resp *http.Response
-	resp, err = http.Get(a.baseURL.ResolveReference(&url.URL{Path: path}).String())
+	resp, err = httpclient.Default().Do(req)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 
+	entry.FetchedAt = time.Now()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		notModified = true
+		return
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
 	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf(
+			"unexpected status code %d for GET %s",
+			resp.StatusCode, httpclient.RedactURL(req.URL.String()),
+		)
 		return
 	}
 
-	contents, err = io.ReadAll(resp.Body)
+	entry.Contents, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return
 	}
-	found = true
+	entry.Found = true
+	entry.ETag = resp.Header.Get("ETag")
+	entry.LastModified = resp.Header.Get("Last-Modified")
 
 	return
 }
@@ -44,10 +87,6 @@ func (a remoteAPI) LocalAPIFS() (fs afero.Fs) {
 	return a.localAPIFS
 }
 
-func (a remoteAPI) Location() Location {
-	return Remote
-}
-
 // SaveContents is currently not supported by the remote API.
 func (a remoteAPI) SaveContents(_ string, _ []byte) (err error) {
 	return fmt.Errorf("not implemented")
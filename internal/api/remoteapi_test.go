@@ -42,35 +42,3 @@ func Test_remoteAPI_GetLocalAPIFS(t *testing.T) {
 		})
 	}
 }
-
-func Test_remoteAPI_GetLocation(t *testing.T) {
-	type fields struct {
-		BaseURL    *url.URL
-		LocalAPIFS afero.Fs
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		want   Location
-	}{
-		{
-			name: "should work",
-			fields: fields{
-				BaseURL:    &url.URL{},
-				LocalAPIFS: nil,
-			},
-			want: Remote,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			a := remoteAPI{
-				baseURL:    tt.fields.BaseURL,
-				localAPIFS: tt.fields.LocalAPIFS,
-			}
-			if got := a.Location(); got != tt.want {
-				t.Errorf("remoteAPI.GetLocation() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
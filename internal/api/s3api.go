@@ -0,0 +1,78 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/s3"
+)
+
+// s3API is an API that is stored under a prefix of an S3-compatible bucket.
+type s3API struct {
+	client     *s3.Client
+	prefix     string
+	localAPIFS afero.Fs
+}
+
+// NewS3API returns an API that is stored in an S3-compatible bucket.
+func NewS3API(localAPIFS afero.Fs, config s3.Config, prefix string) (ToolctlAPI, error) {
+	client, err := s3.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &s3API{client: client, prefix: strings.Trim(prefix, "/"), localAPIFS: localAPIFS}, nil
+}
+
+// newS3Backend returns an S3 API for s3://bucket/prefix URLs. The endpoint and
+// region can be set with the endpoint and region query parameters, and
+// otherwise come from the standard AWS environment variables, like the
+// credentials.
+func newS3Backend(registryURL *url.URL, options BackendOptions) (ToolctlAPI, error) {
+	query := registryURL.Query()
+	config := s3.Config{
+		Endpoint: cmp.Or(
+			query.Get("endpoint"), os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"),
+		),
+		Region: cmp.Or(
+			query.Get("region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"),
+		),
+		Bucket:      registryURL.Host,
+		Credentials: s3.CredentialsFromEnv(),
+	}
+	toolctlAPI, err := NewS3API(options.LocalAPIFS, config, registryURL.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry %s: %w", registryURL.Redacted(), err)
+	}
+	return toolctlAPI, nil
+}
+
+func (a *s3API) GetContents(path string) (found bool, contents []byte, err error) {
+	return a.client.GetObject(a.key(path))
+}
+
+func (a *s3API) SaveContents(path string, contents []byte) error {
+	contentType := "application/yaml"
+	if strings.HasSuffix(path, ".json") {
+		contentType = "application/json"
+	}
+	return a.client.PutObject(a.key(path), contents, contentType)
+}
+
+// key returns the object key of a path of the API.
+func (a *s3API) key(p string) string {
+	return path.Join(a.prefix, p)
+}
+
+func (a *s3API) LocalAPIBasePath() string {
+	return ""
+}
+
+func (a *s3API) LocalAPIFS() afero.Fs {
+	return a.localAPIFS
+}
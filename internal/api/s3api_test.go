@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/s3/s3test"
+)
+
+func TestS3API(t *testing.T) {
+	server := s3test.NewServer("AKIATOOLCTLTEST")
+	defer server.Close()
+	server.PutObject("toolctl", "api/v0/toolctl-test-tool/meta.yaml", []byte("description: toolctl test tool\n"))
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIATOOLCTLTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL())
+
+	toolctlAPI, err := api.Open("s3://toolctl/api/v0", api.BackendOptions{LocalAPIFS: afero.NewMemMapFs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = api.GetMeta(toolctlAPI)
+	if !errors.Is(err, api.NotFoundError{}) {
+		t.Fatalf("GetMeta() error = %v, want %v", err, api.NotFoundError{})
+	}
+
+	toolMeta, err := api.GetToolMeta(toolctlAPI, api.Tool{Name: "toolctl-test-tool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toolMeta.Description != "toolctl test tool" {
+		t.Errorf("GetToolMeta() description = %v, want %v", toolMeta.Description, "toolctl test tool")
+	}
+
+	wantMeta := api.Meta{Tools: []string{"toolctl-test-tool"}}
+	err = api.SaveMeta(toolctlAPI, wantMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := server.Object("toolctl", "api/v0/meta.yaml"); !found {
+		t.Error("SaveMeta() didn't write api/v0/meta.yaml")
+	}
+
+	meta, err := api.GetMeta(toolctlAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(wantMeta, meta); diff != "" {
+		t.Errorf("GetMeta() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestS3APIErrors(t *testing.T) {
+	server := s3test.NewServer("AKIATOOLCTLTEST")
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAWRONG")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	toolctlAPI, err := api.Open(
+		"s3://toolctl/api/v0?endpoint="+server.URL()+"&region=eu-central-1",
+		api.BackendOptions{LocalAPIFS: afero.NewMemMapFs()},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = toolctlAPI.SaveContents("meta.yaml", []byte("tools: []\n"))
+	if err == nil || !strings.HasSuffix(err.Error(), ": AccessDenied: Forbidden") {
+		t.Errorf("SaveContents() error = %v, want AccessDenied", err)
+	}
+
+	_, err = api.Open("s3:///api/v0", api.BackendOptions{LocalAPIFS: afero.NewMemMapFs()})
+	wantErr := "invalid registry s3:///api/v0: invalid S3 config: bucket is required"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("Open() error = %v, want %v", err, wantErr)
+	}
+}
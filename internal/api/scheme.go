@@ -0,0 +1,421 @@
+//nolint:revive // package name is intentionally concise
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// Version is a version of a tool, parsed according to the version scheme of
+// the tool. Versions of the same scheme can be compared with each other.
+type Version struct {
+	original   string
+	segments   []int
+	prerelease string
+}
+
+// String returns the version as it appears in the API and in download URLs.
+func (v *Version) String() string {
+	return v.original
+}
+
+// Prerelease returns the prerelease component of the version, if any.
+func (v *Version) Prerelease() string {
+	return v.prerelease
+}
+
+// Segments returns the numeric segments of the version, most significant
+// first.
+func (v *Version) Segments() []int {
+	return slices.Clone(v.segments)
+}
+
+// Compare compares the version to another one, returning -1, 0 or 1. The
+// numeric segments are compared first, then the prereleases, using the same
+// precedence rules as semantic versions.
+func (v *Version) Compare(other *Version) int {
+	for i := 0; i < max(len(v.segments), len(other.segments)); i++ {
+		a, b := segmentAt(v.segments, i), segmentAt(other.segments, i)
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	return comparePrereleases(v.prerelease, other.prerelease)
+}
+
+// LessThan checks if the version is lower than another one.
+func (v *Version) LessThan(other *Version) bool {
+	return v.Compare(other) < 0
+}
+
+// GreaterThan checks if the version is higher than another one.
+func (v *Version) GreaterThan(other *Version) bool {
+	return v.Compare(other) > 0
+}
+
+// Equal checks if the version is equal to another one.
+func (v *Version) Equal(other *Version) bool {
+	return v.Compare(other) == 0
+}
+
+// Semver converts the version to a semantic version, e.g. to match it against
+// security advisories. Versions with more than three segments can't be
+// converted.
+func (v *Version) Semver() (*semver.Version, error) {
+	if len(v.segments) > 3 {
+		return nil, fmt.Errorf("%s is not a semantic version", v.original)
+	}
+	s := fmt.Sprintf(
+		"%d.%d.%d", segmentAt(v.segments, 0), segmentAt(v.segments, 1),
+		segmentAt(v.segments, 2),
+	)
+	if v.prerelease != "" {
+		s += "-" + v.prerelease
+	}
+	return semver.NewVersion(s)
+}
+
+func segmentAt(segments []int, i int) int {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return 0
+}
+
+// comparePrereleases compares prerelease components. A version without a
+// prerelease is higher than the same version with one.
+func comparePrereleases(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	versionA, errA := semver.NewVersion("0.0.0-" + a)
+	versionB, errB := semver.NewVersion("0.0.0-" + b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return versionA.Compare(versionB)
+}
+
+// VersionScheme parses, orders and generates the versions of a tool.
+type VersionScheme interface {
+	// Parse parses a version.
+	Parse(version string) (*Version, error)
+	// Initial returns the version that discovery starts from if there are no
+	// versions yet, or nil if the scheme doesn't have one.
+	Initial() *Version
+	// Components returns the names of the components of a version, most
+	// significant first.
+	Components() []string
+	// Increment returns the next candidate version after a version, by
+	// incrementing the component with the given index and resetting all less
+	// significant components.
+	Increment(version *Version, component int) (*Version, error)
+	// Regex returns a regular expression that matches versions of the scheme,
+	// in the output of a tool binary.
+	Regex() string
+}
+
+// DefaultVersionScheme is used by tools that don't specify a version scheme.
+const DefaultVersionScheme = "semver"
+
+var versionSchemes = map[string]VersionScheme{
+	"semver":    semverScheme{},
+	"calver":    calverScheme{},
+	"four-part": fourPartScheme{},
+	"date":      dateScheme{},
+}
+
+// GetVersionScheme returns the version scheme of a tool.
+func GetVersionScheme(toolMeta ToolMeta) (VersionScheme, error) {
+	name := toolMeta.VersionScheme
+	if name == "" {
+		name = DefaultVersionScheme
+	}
+	scheme, ok := versionSchemes[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"invalid versionScheme %s, must be semver, calver, four-part or date", name,
+		)
+	}
+	return scheme, nil
+}
+
+// ParseVersion parses a version of a tool, according to its version scheme.
+func ParseVersion(toolMeta ToolMeta, version string) (*Version, error) {
+	scheme, err := GetVersionScheme(toolMeta)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.Parse(version)
+}
+
+// semverScheme handles semantic versions like 1.2.3 and 1.2.3-rc.1. Versions
+// are normalized, so 1.2 becomes 1.2.0.
+type semverScheme struct{}
+
+func (semverScheme) Parse(version string) (*Version, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return fromSemver(v), nil
+}
+
+func (semverScheme) Initial() *Version {
+	return fromSemver(semver.MustParse("0.0.0"))
+}
+
+func (semverScheme) Components() []string {
+	return []string{"major", "minor", "patch"}
+}
+
+func (semverScheme) Increment(version *Version, component int) (*Version, error) {
+	v, err := semver.NewVersion(version.original)
+	if err != nil {
+		return nil, err
+	}
+
+	var incremented semver.Version
+	switch component {
+	case 0:
+		incremented = v.IncMajor()
+	case 1:
+		incremented = v.IncMinor()
+	case 2:
+		incremented = v.IncPatch()
+	default:
+		return nil, fmt.Errorf("invalid version component: %d", component)
+	}
+	return fromSemver(&incremented), nil
+}
+
+// Regex matches only the version core, as suffixes like -linux-amd64 are
+// often not prereleases. Tools that print prereleases have to opt in with a
+// versionRegex that captures them.
+func (semverScheme) Regex() string {
+	return `(\d+\.\d+\.\d+)`
+}
+
+func fromSemver(v *semver.Version) *Version {
+	return &Version{
+		original:   v.String(),
+		segments:   []int{int(v.Major()), int(v.Minor()), int(v.Patch())},
+		prerelease: v.Prerelease(),
+	}
+}
+
+// segmentsRegex matches dot-separated numeric segments with an optional
+// prerelease, e.g. 2024.03.1 or 1.2.3.4-beta.1.
+var segmentsRegex = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:-([0-9A-Za-z.-]+))?$`)
+
+// parseSegments parses a version of numeric segments. The zero-padding of the
+// segments is kept, so that incremented versions are formatted the same way.
+func parseSegments(
+	version string, minSegments, maxSegments int,
+) (v *Version, widths []int, err error) {
+	match := segmentsRegex.FindStringSubmatch(version)
+	if match == nil {
+		return nil, nil, fmt.Errorf("invalid version: %s", version)
+	}
+
+	parts := strings.Split(match[1], ".")
+	if len(parts) < minSegments || len(parts) > maxSegments {
+		return nil, nil, fmt.Errorf("invalid version: %s", version)
+	}
+
+	v = &Version{prerelease: match[2]}
+	for _, part := range parts {
+		var segment int
+		segment, err = strconv.Atoi(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid version: %s", version)
+		}
+		v.segments = append(v.segments, segment)
+		widths = append(widths, len(part))
+	}
+	v.original = strings.TrimPrefix(version, "v")
+	return
+}
+
+// formatSegments formats numeric segments with the given zero-padding.
+func formatSegments(segments []int, widths []int) string {
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		width := 1
+		if i < len(widths) && widths[i] > 1 && len(strconv.Itoa(segment)) < widths[i] {
+			width = widths[i]
+		}
+		parts[i] = fmt.Sprintf("%0*d", width, segment)
+	}
+	return strings.Join(parts, ".")
+}
+
+// calverScheme handles calendar versions like 2024.3.1 and 2024.03 (year,
+// month and an optional micro version).
+type calverScheme struct{}
+
+func (calverScheme) Parse(version string) (*Version, error) {
+	v, _, err := parseSegments(version, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+	if v.segments[1] < 1 || v.segments[1] > 12 {
+		return nil, fmt.Errorf("invalid version: %s, month must be between 1 and 12", version)
+	}
+	return v, nil
+}
+
+func (calverScheme) Initial() *Version { return nil }
+
+func (calverScheme) Components() []string {
+	return []string{"year", "month", "micro"}
+}
+
+func (calverScheme) Increment(version *Version, component int) (*Version, error) {
+	_, widths, err := parseSegments(version.original, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	year, month, micro := segmentAt(version.segments, 0), segmentAt(version.segments, 1),
+		segmentAt(version.segments, 2)
+	switch component {
+	case 0:
+		year, month, micro = year+1, 1, 0
+	case 1:
+		month, micro = month+1, 0
+		if month > 12 {
+			year, month = year+1, 1
+		}
+	case 2:
+		micro++
+	default:
+		return nil, fmt.Errorf("invalid version component: %d", component)
+	}
+
+	segments := []int{year, month}
+	if len(version.segments) > 2 || micro > 0 {
+		segments = append(segments, micro)
+	}
+	return &Version{
+		original: formatSegments(segments, widths),
+		segments: segments,
+	}, nil
+}
+
+func (calverScheme) Regex() string {
+	return `v?(\d{4}\.\d{1,2}(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)`
+}
+
+// fourPartScheme handles versions with four numeric components like 1.2.3.4,
+// as used by many Windows and Java tools.
+type fourPartScheme struct{}
+
+func (fourPartScheme) Parse(version string) (*Version, error) {
+	v, _, err := parseSegments(version, 4, 4)
+	return v, err
+}
+
+func (fourPartScheme) Initial() *Version {
+	return &Version{original: "0.0.0.0", segments: []int{0, 0, 0, 0}}
+}
+
+func (fourPartScheme) Components() []string {
+	return []string{"major", "minor", "patch", "build"}
+}
+
+func (fourPartScheme) Increment(version *Version, component int) (*Version, error) {
+	if component < 0 || component > 3 {
+		return nil, fmt.Errorf("invalid version component: %d", component)
+	}
+	segments := make([]int, 4)
+	copy(segments, version.segments)
+	segments[component]++
+	for i := component + 1; i < len(segments); i++ {
+		segments[i] = 0
+	}
+	return &Version{original: formatSegments(segments, nil), segments: segments}, nil
+}
+
+func (fourPartScheme) Regex() string {
+	return `v?(\d+\.\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`
+}
+
+// dateScheme handles date tags like 20240315, 2024-03-15 and 2024.03.15.
+type dateScheme struct{}
+
+var dateLayouts = []string{"20060102", "2006-01-02", "2006.01.02"}
+
+func (dateScheme) Parse(version string) (*Version, error) {
+	_, layout, err := parseDate(version)
+	if err != nil {
+		return nil, err
+	}
+	return formatDate(version, layout)
+}
+
+func (dateScheme) Initial() *Version { return nil }
+
+func (dateScheme) Components() []string {
+	return []string{"year", "month", "day"}
+}
+
+func (dateScheme) Increment(version *Version, component int) (*Version, error) {
+	date, layout, err := parseDate(version.original)
+	if err != nil {
+		return nil, err
+	}
+
+	switch component {
+	case 0:
+		date = time.Date(date.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	case 1:
+		date = time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	case 2:
+		date = date.AddDate(0, 0, 1)
+	default:
+		return nil, fmt.Errorf("invalid version component: %d", component)
+	}
+	return formatDate(date.Format(layout), layout)
+}
+
+func (dateScheme) Regex() string {
+	return `(\d{4}-\d{2}-\d{2}|\d{4}\.\d{2}\.\d{2}|\d{8})`
+}
+
+// parseDate parses a date tag in one of the supported layouts.
+func parseDate(version string) (date time.Time, layout string, err error) {
+	for _, layout = range dateLayouts {
+		date, err = time.Parse(layout, version)
+		if err == nil {
+			return
+		}
+	}
+	err = fmt.Errorf("invalid version: %s, must be a date like 20060102 or 2006-01-02", version)
+	return
+}
+
+func formatDate(version string, layout string) (*Version, error) {
+	date, err := time.Parse(layout, version)
+	if err != nil {
+		return nil, err
+	}
+	return &Version{
+		original: version,
+		segments: []int{date.Year(), int(date.Month()), date.Day()},
+	}, nil
+}
@@ -0,0 +1,139 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		scheme     string
+		version    string
+		want       string
+		wantErrStr string
+	}{
+		{name: "semver", version: "1.2.3", want: "1.2.3"},
+		{name: "semver is normalized", version: "v1.2", want: "1.2.0"},
+		{
+			name:       "invalid semver",
+			version:    "1.2.3.4",
+			wantErrStr: "Invalid Semantic Version",
+		},
+		{name: "calver", scheme: "calver", version: "2024.03.1", want: "2024.03.1"},
+		{name: "calver without micro", scheme: "calver", version: "2024.3", want: "2024.3"},
+		{
+			name:       "calver with invalid month",
+			scheme:     "calver",
+			version:    "2024.13.1",
+			wantErrStr: "invalid version: 2024.13.1, month must be between 1 and 12",
+		},
+		{name: "four-part", scheme: "four-part", version: "v1.2.3.4", want: "1.2.3.4"},
+		{
+			name:       "four-part with three parts",
+			scheme:     "four-part",
+			version:    "1.2.3",
+			wantErrStr: "invalid version: 1.2.3",
+		},
+		{name: "date", scheme: "date", version: "20240315", want: "20240315"},
+		{name: "date with dashes", scheme: "date", version: "2024-03-15", want: "2024-03-15"},
+		{
+			name:       "invalid date",
+			scheme:     "date",
+			version:    "20240230",
+			wantErrStr: "invalid version: 20240230, must be a date like 20060102 or 2006-01-02",
+		},
+		{
+			name:       "unknown scheme",
+			scheme:     "roman",
+			version:    "IV",
+			wantErrStr: "invalid versionScheme roman, must be semver, calver, four-part or date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := api.ParseVersion(api.ToolMeta{VersionScheme: tt.scheme}, tt.version)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("ParseVersion() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil {
+				if err.Error() != tt.wantErrStr {
+					t.Errorf("ParseVersion() error = %v, wantErr %v", err, tt.wantErrStr)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		scheme string
+		a      string
+		b      string
+		want   int
+	}{
+		{scheme: "semver", a: "1.2.3", b: "1.10.0", want: -1},
+		{scheme: "semver", a: "1.2.3-rc.1", b: "1.2.3", want: -1},
+		{scheme: "semver", a: "1.2.3-rc.10", b: "1.2.3-rc.2", want: 1},
+		{scheme: "calver", a: "2024.03.1", b: "2024.3.1", want: 0},
+		{scheme: "calver", a: "2024.12", b: "2025.01", want: -1},
+		{scheme: "four-part", a: "1.2.3.10", b: "1.2.3.9", want: 1},
+		{scheme: "date", a: "2024-03-15", b: "2024-02-29", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme+" "+tt.a+" "+tt.b, func(t *testing.T) {
+			toolMeta := api.ToolMeta{VersionScheme: tt.scheme}
+			a := mustParseVersion(t, toolMeta, tt.a)
+			b := mustParseVersion(t, toolMeta, tt.b)
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionSchemeIncrement(t *testing.T) {
+	tests := []struct {
+		scheme    string
+		version   string
+		component int
+		want      string
+	}{
+		{scheme: "semver", version: "1.2.3", component: 0, want: "2.0.0"},
+		{scheme: "semver", version: "1.2.3", component: 1, want: "1.3.0"},
+		{scheme: "semver", version: "1.2.3", component: 2, want: "1.2.4"},
+		{scheme: "semver", version: "1.3.0-rc.1", component: 2, want: "1.3.0"},
+		{scheme: "calver", version: "2024.03.1", component: 2, want: "2024.03.2"},
+		{scheme: "calver", version: "2024.09.1", component: 1, want: "2024.10.0"},
+		{scheme: "calver", version: "2024.12.1", component: 1, want: "2025.01.0"},
+		{scheme: "calver", version: "2024.3", component: 1, want: "2024.4"},
+		{scheme: "calver", version: "2024.3.1", component: 0, want: "2025.1.0"},
+		{scheme: "four-part", version: "1.2.3.4", component: 3, want: "1.2.3.5"},
+		{scheme: "four-part", version: "1.2.3.4", component: 1, want: "1.3.0.0"},
+		{scheme: "date", version: "20240228", component: 2, want: "20240229"},
+		{scheme: "date", version: "2024-12-31", component: 2, want: "2025-01-01"},
+		{scheme: "date", version: "2024.03.15", component: 1, want: "2024.04.01"},
+		{scheme: "date", version: "20240315", component: 0, want: "20250101"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme+" "+tt.version, func(t *testing.T) {
+			toolMeta := api.ToolMeta{VersionScheme: tt.scheme}
+			scheme, err := api.GetVersionScheme(toolMeta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := scheme.Increment(mustParseVersion(t, toolMeta, tt.version), tt.component)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Increment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,15 @@ import (
 
 const localAPIBasePath = "/toolctl/tools/v0"
 
+// apiLocation selects whether setupTest serves the API contents over HTTP or
+// from the local file system.
+type apiLocation int
+
+const (
+	localAPILocation apiLocation = iota
+	remoteAPILocation
+)
+
 type apiContents []apiFile
 
 type apiFile struct {
@@ -17,7 +26,7 @@ type apiFile struct {
 	Contents string
 }
 
-func setupTest(apiLocation api.Location, apiContents apiContents) (
+func setupTest(apiLocation apiLocation, apiContents apiContents) (
 	toolctlAPI api.ToolctlAPI, apiServer *httptest.Server, err error,
 ) {
 	localAPIFS := afero.NewMemMapFs()
@@ -29,7 +38,7 @@ func setupTest(apiLocation api.Location, apiContents apiContents) (
 		}
 	}
 
-	if apiLocation == api.Remote {
+	if apiLocation == remoteAPILocation {
 		apiFileServer := http.FileServer(afero.NewHttpFs(localAPIFS).Dir(localAPIBasePath))
 		apiServer = httptest.NewServer(apiFileServer)
 
@@ -41,7 +50,7 @@ func setupTest(apiLocation api.Location, apiContents apiContents) (
 		return
 	}
 
-	if apiLocation == api.Local {
+	if apiLocation == localAPILocation {
 		toolctlAPI, err = api.NewLocalAPI(localAPIFS, localAPIBasePath)
 		if err != nil {
 			return
@@ -2,20 +2,174 @@
 package api
 
 import (
-	"github.com/Masterminds/semver"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
 )
 
+// StableChannel is the release channel of versions without a prerelease
+// component.
+const StableChannel = "stable"
+
 // GetLatestVersion returns the latest version for the given tool, OS and arch
-func GetLatestVersion(toolctlAPI ToolctlAPI, tool Tool) (version *semver.Version, err error) {
+func GetLatestVersion(toolctlAPI ToolctlAPI, tool Tool) (version *Version, err error) {
+	toolMeta, err := GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	toolPlatformMeta, err := GetToolPlatformMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+	if toolPlatformMeta.Version.Latest == "" {
+		err = fmt.Errorf("%s latest version %w", tool.Name, NotFoundError{})
+		return
+	}
+
+	version, err = ParseVersion(toolMeta, toolPlatformMeta.Version.Latest)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetLatestVersionInChannel returns the latest version for the given tool, OS
+// and arch in a release channel. Stable versions are part of every channel, so
+// a prerelease is only returned if it is newer than the latest stable version.
+func GetLatestVersionInChannel(
+	toolctlAPI ToolctlAPI, tool Tool, channel string,
+) (version *Version, err error) {
+	if channel == "" || channel == StableChannel {
+		return GetLatestVersion(toolctlAPI, tool)
+	}
+
+	toolMeta, err := GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
 	toolPlatformMeta, err := GetToolPlatformMeta(toolctlAPI, tool)
 	if err != nil {
 		return
 	}
 
-	version, err = semver.NewVersion(toolPlatformMeta.Version.Latest)
+	prerelease, ok := toolPlatformMeta.Version.Channels[channel]
+	if !ok {
+		err = fmt.Errorf("%s %s channel %w", tool.Name, channel, NotFoundError{})
+		return
+	}
+	version, err = ParseVersion(toolMeta, prerelease)
 	if err != nil {
 		return
 	}
 
+	if toolPlatformMeta.Version.Latest != "" {
+		var stableVersion *Version
+		stableVersion, err = ParseVersion(toolMeta, toolPlatformMeta.Version.Latest)
+		if err != nil {
+			return
+		}
+		if stableVersion.GreaterThan(version) {
+			version = stableVersion
+		}
+	}
+
+	return
+}
+
+// VersionChannel returns the release channel of a version. It is derived from
+// the first identifier of the prerelease component, e.g. "rc" for 1.2.0-rc.1
+// and "beta" for 1.2.0-beta2.
+func VersionChannel(version *Version) string {
+	prerelease := version.Prerelease()
+	if prerelease == "" {
+		return StableChannel
+	}
+
+	identifier := strings.SplitN(prerelease, ".", 2)[0]
+	channel := strings.ToLower(strings.TrimRight(identifier, "0123456789-"))
+	if channel == "" {
+		return "prerelease"
+	}
+	return channel
+}
+
+// GetVersions returns all versions for the given tool, OS and arch, newest
+// first. The versions of a local API are listed from its files, and those of
+// other APIs are taken from their index. APIs without an index can't be
+// listed, so no versions are returned for them.
+func GetVersions(toolctlAPI ToolctlAPI, tool Tool) (versions []*Version, err error) {
+	toolMeta, err := GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	var versionStrings []string
+	if toolctlAPI.LocalAPIBasePath() != "" {
+		versionStrings, err = listLocalVersions(toolctlAPI, tool)
+	} else {
+		versionStrings, err = listIndexVersions(toolctlAPI, tool)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, versionString := range versionStrings {
+		var version *Version
+		version, err = ParseVersion(toolMeta, versionString)
+		if err != nil {
+			return
+		}
+		versions = append(versions, version)
+	}
+	slices.SortFunc(versions, func(a, b *Version) int {
+		return b.Compare(a)
+	})
+	return
+}
+
+// listLocalVersions lists the version files of a tool platform in a local API.
+func listLocalVersions(toolctlAPI ToolctlAPI, tool Tool) (versions []string, err error) {
+	files, err := afero.Glob(
+		toolctlAPI.LocalAPIFS(),
+		filepath.Join(toolctlAPI.LocalAPIBasePath(), tool.Name, tool.OS+"-"+tool.Arch, "*.yaml"),
+	)
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		version := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		if version != "meta" {
+			versions = append(versions, version)
+		}
+	}
+	return
+}
+
+// listIndexVersions lists the versions of a tool platform in the index of an
+// API. APIs without an index have no versions.
+func listIndexVersions(toolctlAPI ToolctlAPI, tool Tool) (versions []string, err error) {
+	found, contents, err := toolctlAPI.GetContents(IndexPath)
+	if err != nil || !found {
+		return
+	}
+
+	var index Index
+	err = json.Unmarshal(contents, &index)
+	if err != nil {
+		err = fmt.Errorf("invalid %s: %w", IndexPath, err)
+		return
+	}
+
+	for version := range index.Tools[tool.Name].Platforms[tool.OS+"-"+tool.Arch].Versions {
+		versions = append(versions, version)
+	}
 	return
 }
@@ -1,11 +1,11 @@
 package api_test
 
 import (
+	"encoding/json"
 	"path"
 	"reflect"
 	"testing"
 
-	"github.com/Masterminds/semver"
 	"github.com/toolctl/toolctl/internal/api"
 )
 
@@ -18,7 +18,7 @@ func TestGetLatestVersion(t *testing.T) {
 		name        string
 		apiContents apiContents
 		args        args
-		want        *semver.Version
+		want        *api.Version
 		wantErr     bool
 	}{
 		{
@@ -27,6 +27,9 @@ func TestGetLatestVersion(t *testing.T) {
 				apiFile{
 					Path: path.Join(localAPIBasePath, "meta.yaml"),
 				},
+				apiFile{
+					Path: path.Join(localAPIBasePath, "toolctl-test-tool/meta.yaml"),
+				},
 				apiFile{
 					Path: path.Join(localAPIBasePath, "toolctl-test-tool/darwin-amd64/meta.yaml"),
 					Contents: `version:
@@ -42,7 +45,34 @@ func TestGetLatestVersion(t *testing.T) {
 					Arch: "amd64",
 				},
 			},
-			want: semver.MustParse("1.3.2"),
+			want: mustParseVersion(t, api.ToolMeta{}, "1.3.2"),
+		},
+		{
+			name: "supported tool with calendar versions",
+			apiContents: apiContents{
+				apiFile{
+					Path: path.Join(localAPIBasePath, "meta.yaml"),
+				},
+				apiFile{
+					Path:     path.Join(localAPIBasePath, "toolctl-test-tool/meta.yaml"),
+					Contents: "versionScheme: calver\n",
+				},
+				apiFile{
+					Path: path.Join(localAPIBasePath, "toolctl-test-tool/darwin-amd64/meta.yaml"),
+					Contents: `version:
+  earliest: 2023.12.0
+  latest: 2024.03.1
+`,
+				},
+			},
+			args: args{
+				tool: api.Tool{
+					Name: "toolctl-test-tool",
+					OS:   "darwin",
+					Arch: "amd64",
+				},
+			},
+			want: mustParseVersion(t, api.ToolMeta{VersionScheme: "calver"}, "2024.03.1"),
 		},
 		{
 			name: "unsupported tool",
@@ -57,7 +87,7 @@ func TestGetLatestVersion(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		for _, apiLocation := range []api.Location{api.Remote, api.Local} {
+		for _, apiLocation := range []apiLocation{remoteAPILocation, localAPILocation} {
 			toolctlAPI, apiServer, err := setupTest(apiLocation, tt.apiContents)
 			if err != nil {
 				t.Fatal(err)
@@ -74,9 +104,207 @@ func TestGetLatestVersion(t *testing.T) {
 				}
 			})
 
-			if apiLocation == api.Remote {
+			if apiLocation == remoteAPILocation {
 				apiServer.Close()
 			}
 		}
 	}
 }
+
+func TestGetLatestVersionInChannel(t *testing.T) {
+	apiContents := apiContents{
+		apiFile{
+			Path: path.Join(localAPIBasePath, "meta.yaml"),
+		},
+		apiFile{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool/meta.yaml"),
+		},
+		apiFile{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool/darwin-amd64/meta.yaml"),
+			Contents: `version:
+  earliest: 1.0.0
+  latest: 1.3.2
+  channels:
+    beta: 1.4.0-beta.2
+    rc: 1.3.0-rc.1
+`,
+		},
+	}
+	tool := api.Tool{
+		Name: "toolctl-test-tool",
+		OS:   "darwin",
+		Arch: "amd64",
+	}
+
+	tests := []struct {
+		name       string
+		channel    string
+		want       *api.Version
+		wantErrStr string
+	}{
+		{
+			name:    "stable",
+			channel: api.StableChannel,
+			want:    mustParseVersion(t, api.ToolMeta{}, "1.3.2"),
+		},
+		{
+			name:    "prerelease newer than stable",
+			channel: "beta",
+			want:    mustParseVersion(t, api.ToolMeta{}, "1.4.0-beta.2"),
+		},
+		{
+			name:    "prerelease older than stable",
+			channel: "rc",
+			want:    mustParseVersion(t, api.ToolMeta{}, "1.3.2"),
+		},
+		{
+			name:       "unknown channel",
+			channel:    "alpha",
+			wantErrStr: "toolctl-test-tool alpha channel could not be found",
+		},
+	}
+	for _, tt := range tests {
+		for _, apiLocation := range []apiLocation{remoteAPILocation, localAPILocation} {
+			toolctlAPI, apiServer, err := setupTest(apiLocation, apiContents)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := api.GetLatestVersionInChannel(toolctlAPI, tool, tt.channel)
+				if (err == nil) != (tt.wantErrStr == "") {
+					t.Fatalf("GetLatestVersionInChannel() error = %v, wantErr %v", err, tt.wantErrStr)
+				}
+				if err != nil && err.Error() != tt.wantErrStr {
+					t.Errorf("GetLatestVersionInChannel() error = %v, wantErr %v", err, tt.wantErrStr)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("GetLatestVersionInChannel() = %v, want %v", got, tt.want)
+				}
+			})
+
+			if apiLocation == remoteAPILocation {
+				apiServer.Close()
+			}
+		}
+	}
+}
+
+func TestVersionChannel(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "1.2.0", want: api.StableChannel},
+		{version: "1.2.0+build.1", want: api.StableChannel},
+		{version: "1.2.0-rc.1", want: "rc"},
+		{version: "1.2.0-beta2", want: "beta"},
+		{version: "1.2.0-Alpha", want: "alpha"},
+		{version: "1.2.0-0.3.7", want: "prerelease"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := api.VersionChannel(mustParseVersion(t, api.ToolMeta{}, tt.version)); got != tt.want {
+				t.Errorf("VersionChannel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParseVersion(t *testing.T, toolMeta api.ToolMeta, version string) *api.Version {
+	t.Helper()
+	v, err := api.ParseVersion(toolMeta, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestGetVersions(t *testing.T) {
+	contents := apiContents{
+		apiFile{
+			Path:     path.Join(localAPIBasePath, "meta.yaml"),
+			Contents: "tools:\n  - toolctl-test-tool\n",
+		},
+		apiFile{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool/meta.yaml"),
+		},
+		apiFile{
+			Path: path.Join(localAPIBasePath, "toolctl-test-tool/linux-amd64/meta.yaml"),
+			Contents: `version:
+  earliest: 0.1.0
+  latest: 0.10.0
+`,
+		},
+		apiFile{Path: path.Join(localAPIBasePath, "toolctl-test-tool/linux-amd64/0.1.0.yaml")},
+		apiFile{Path: path.Join(localAPIBasePath, "toolctl-test-tool/linux-amd64/0.10.0.yaml")},
+		apiFile{Path: path.Join(localAPIBasePath, "toolctl-test-tool/linux-amd64/0.2.0.yaml")},
+	}
+	tool := api.Tool{Name: "toolctl-test-tool", OS: "linux", Arch: "amd64"}
+	want := []string{"0.10.0", "0.2.0", "0.1.0"}
+
+	localAPI, _, err := setupTest(localAPILocation, contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := api.BuildIndex(localAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexContents, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remoteAPI, remoteAPIServer, err := setupTest(remoteAPILocation, contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remoteAPIServer.Close()
+
+	indexedRemoteAPI, indexedRemoteAPIServer, err := setupTest(remoteAPILocation, append(contents, apiFile{
+		Path:     path.Join(localAPIBasePath, api.IndexPath),
+		Contents: string(indexContents),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexedRemoteAPIServer.Close()
+
+	tests := []struct {
+		name       string
+		toolctlAPI api.ToolctlAPI
+		want       []string
+	}{
+		{
+			name:       "local API",
+			toolctlAPI: localAPI,
+			want:       want,
+		},
+		{
+			name:       "remote API without index",
+			toolctlAPI: remoteAPI,
+		},
+		{
+			name:       "remote API with index",
+			toolctlAPI: indexedRemoteAPI,
+			want:       want,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, err := api.GetVersions(tt.toolctlAPI, tool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, version := range versions {
+				got = append(got, version.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+// Package apiserver serves a local API over HTTP, with the same paths that the
+// remote API requests, so that it can be used as a registry for testing and in
+// air-gapped networks.
+package apiserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/httpclient"
+	"github.com/toolctl/toolctl/internal/verify"
+)
+
+// DownloadsPath is the path below which the download mirror is served.
+const DownloadsPath = "/downloads/"
+
+// indexTTL is how long the index is reused to look up downloads, so that it
+// isn't rebuilt for every download that isn't stored yet. Versions that were
+// added in the meantime can be mirrored once it has expired.
+const indexTTL = time.Minute
+
+// Options configures the server.
+type Options struct {
+	// FS is the file system of the API and of the downloads directory.
+	FS afero.Fs
+	// BasePath is the directory of the API.
+	BasePath string
+	// Index generates index.json from the API on every request, so that it is
+	// always up to date, instead of serving the file written by api sync.
+	Index bool
+	// Gzip compresses responses for clients that accept it.
+	Gzip bool
+	// DownloadsDir enables the download mirror, if set. Downloads are stored
+	// as <host>/<path> of their URL, e.g. github.com/derailed/k9s/releases/...,
+	// and fetched from upstream on first use. Only the URLs of the API are
+	// mirrored, and their checksums are verified before they are stored.
+	DownloadsDir string
+}
+
+// Server is the HTTP handler that serves the API.
+type Server struct {
+	options Options
+
+	// fetches holds the downloads that are being fetched, by file path, so
+	// that concurrent requests for the same download share a single fetch,
+	// while different downloads are fetched in parallel.
+	fetchesMu sync.Mutex
+	fetches   map[string]*fetch
+
+	indexMu      sync.Mutex
+	index        *api.Index
+	indexBuiltAt time.Time
+}
+
+// fetch is a download that is being fetched. Its error is set before done is
+// closed.
+type fetch struct {
+	done chan struct{}
+	err  error
+}
+
+// New returns a server for the given options.
+func New(options Options) *Server {
+	return &Server{options: options, fetches: map[string]*fetch{}}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	switch {
+	case s.options.DownloadsDir != "" && strings.HasPrefix(urlPath, DownloadsPath):
+		s.serveDownload(w, r, strings.TrimPrefix(urlPath, DownloadsPath))
+	case s.options.Index && urlPath == "/"+api.IndexPath:
+		s.serveIndex(w, r)
+	default:
+		s.serveFile(w, r, urlPath)
+	}
+}
+
+// serveFile serves a file of the API. Directories are not listed.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, urlPath string) {
+	filePath := filepath.Join(s.options.BasePath, filepath.FromSlash(urlPath))
+	info, err := s.options.FS.Stat(filePath)
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	contents, err := afero.ReadFile(s.options.FS, filePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.serveContents(w, r, contents, contentType(urlPath))
+}
+
+// serveIndex generates and serves the index of the API.
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	index, err := s.getIndex(0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	contents, err := json.Marshal(index)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.serveContents(w, r, contents, contentType(api.IndexPath))
+}
+
+// getIndex returns the index of the API. It is rebuilt if it is older than
+// maxAge, so a maxAge of 0 always rebuilds it.
+func (s *Server) getIndex(maxAge time.Duration) (index api.Index, err error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	if s.index != nil && time.Since(s.indexBuiltAt) < maxAge {
+		return *s.index, nil
+	}
+
+	localAPI, err := api.NewLocalAPI(s.options.FS, s.options.BasePath)
+	if err != nil {
+		return
+	}
+	index, err = api.BuildIndex(localAPI)
+	if err != nil {
+		return
+	}
+	s.index = &index
+	s.indexBuiltAt = time.Now()
+	return
+}
+
+// serveContents serves the contents with an ETag, answering conditional
+// requests with 304 Not Modified, and compressed if the client accepts it.
+func (s *Server) serveContents(
+	w http.ResponseWriter, r *http.Request, contents []byte, contentType string,
+) {
+	sum := sha256.Sum256(contents)
+	etag := hex.EncodeToString(sum[:])
+	compress := s.options.Gzip && acceptsGzip(r)
+	if compress {
+		// The compressed representation needs a different ETag
+		etag += "-gzip"
+	}
+	etag = `"` + etag + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", contentType)
+	if s.options.Gzip {
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if compress {
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		_, _ = gzipWriter.Write(contents)
+		_ = gzipWriter.Close()
+		contents = buf.Bytes()
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(contents)
+	}
+}
+
+// serveDownload serves a file of the download mirror, fetching it from
+// upstream if it isn't stored yet.
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request, mirrorPath string) {
+	filePath := filepath.Join(s.options.DownloadsDir, filepath.FromSlash(mirrorPath))
+	info, err := s.options.FS.Stat(filePath)
+	if err != nil {
+		err = s.fetchDownloadOnce(mirrorPath, filePath)
+		if err != nil {
+			if errors.Is(err, notMirroredError{}) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		info, err = s.options.FS.Stat(filePath)
+	}
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := s.options.FS.Open(filePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// notMirroredError is returned for downloads that the API doesn't refer to.
+type notMirroredError struct{}
+
+func (notMirroredError) Error() string {
+	return "not mirrored"
+}
+
+// fetchDownloadOnce fetches a download, unless it is already being fetched, in
+// which case it waits for that fetch to finish.
+func (s *Server) fetchDownloadOnce(mirrorPath string, filePath string) error {
+	s.fetchesMu.Lock()
+	if f, ok := s.fetches[filePath]; ok {
+		s.fetchesMu.Unlock()
+		<-f.done
+		return f.err
+	}
+	f := &fetch{done: make(chan struct{})}
+	s.fetches[filePath] = f
+	s.fetchesMu.Unlock()
+
+	f.err = s.fetchDownload(mirrorPath, filePath)
+
+	s.fetchesMu.Lock()
+	delete(s.fetches, filePath)
+	s.fetchesMu.Unlock()
+	close(f.done)
+	return f.err
+}
+
+// fetchDownload fetches a download that the API refers to from upstream,
+// verifies its checksums, and stores it in the downloads directory.
+func (s *Server) fetchDownload(mirrorPath string, filePath string) (err error) {
+	// Another request may have fetched it in the meantime
+	if _, err = s.options.FS.Stat(filePath); err == nil {
+		return
+	}
+
+	upstreamURL, meta, found, err := s.lookUpDownload(mirrorPath)
+	if err != nil {
+		return
+	}
+	if !found {
+		return notMirroredError{}
+	}
+
+	resp, err := httpclient.Get(upstreamURL)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	err = s.options.FS.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return
+	}
+	tempFile, err := afero.TempFile(s.options.FS, filepath.Dir(filePath), ".download-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = s.options.FS.Remove(tempFile.Name())
+		}
+	}()
+
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tempFile, hasher), resp.Body)
+	closeErr := tempFile.Close()
+	if err != nil {
+		return
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	// The SHA256 key may be omitted if other digests are present
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if (meta.SHA256 != "" || len(meta.Digests) == 0) && sum != meta.SHA256 {
+		return fmt.Errorf("SHA256 hash mismatch, wanted %s, got %s", meta.SHA256, sum)
+	}
+	if len(meta.Digests) > 0 {
+		err = checkDigests(s.options.FS, tempFile.Name(), meta.Digests)
+		if err != nil {
+			return
+		}
+	}
+
+	return s.options.FS.Rename(tempFile.Name(), filePath)
+}
+
+// lookUpDownload finds the version of a tool that a mirror path belongs to.
+func (s *Server) lookUpDownload(
+	mirrorPath string,
+) (upstreamURL string, meta api.ToolPlatformVersionMeta, found bool, err error) {
+	index, err := s.getIndex(indexTTL)
+	if err != nil {
+		return
+	}
+
+	for _, tool := range index.Tools {
+		for _, platform := range tool.Platforms {
+			for _, version := range platform.Versions {
+				for _, rawURL := range append([]string{version.URL}, version.Mirrors...) {
+					u, parseErr := url.Parse(rawURL)
+					if parseErr != nil || (u.Scheme != "http" && u.Scheme != "https") {
+						continue
+					}
+					if u.Host+u.Path == mirrorPath {
+						return rawURL, version, true, nil
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func checkDigests(fs afero.Fs, filePath string, digests map[string]string) (err error) {
+	file, err := fs.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	return verify.CheckDigests(file, digests)
+}
+
+// contentType returns the content type of a file of the API.
+func contentType(urlPath string) string {
+	if strings.HasSuffix(urlPath, ".json") {
+		return "application/json"
+	}
+	return "application/yaml"
+}
+
+// acceptsGzip returns true if the Accept-Encoding header of a request allows
+// gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		name = strings.TrimSpace(name)
+		if (name == "gzip" || name == "*") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesETag returns true if an If-None-Match header matches the ETag.
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
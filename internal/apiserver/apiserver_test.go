@@ -0,0 +1,305 @@
+package apiserver_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/apiserver"
+)
+
+const basePath = "/toolctl/api/v0"
+
+// setupServer serves an API with a single tool version, whose download is
+// served by an upstream server that counts its requests.
+func setupServer(t *testing.T, options apiserver.Options, sha256Override string) (
+	server *httptest.Server, upstreamHost string, upstreamRequests *int,
+) {
+	upstreamRequests = new(int)
+	download := []byte("toolctl test tool")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*upstreamRequests++
+		if r.URL.Path != "/releases/1.0.0/toolctl-test-tool" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(download)
+	}))
+	t.Cleanup(upstream.Close)
+	upstreamHost = strings.TrimPrefix(upstream.URL, "http://")
+
+	sha256Sum := fmt.Sprintf("%x", sha256.Sum256(download))
+	if sha256Override != "" {
+		sha256Sum = sha256Override
+	}
+
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"meta.yaml":                               "tools:\n  - toolctl-test-tool\n",
+		"toolctl-test-tool/meta.yaml":             "description: toolctl test tool\n",
+		"toolctl-test-tool/linux-amd64/meta.yaml": "version:\n  earliest: 1.0.0\n  latest: 1.0.0\n",
+		"toolctl-test-tool/linux-amd64/1.0.0.yaml": "url: " + upstream.URL +
+			"/releases/1.0.0/toolctl-test-tool\nsha256: " + sha256Sum + "\n",
+	}
+	for filePath, contents := range files {
+		err := afero.WriteFile(fs, path.Join(basePath, filePath), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options.FS = fs
+	options.BasePath = basePath
+	server = httptest.NewServer(apiserver.New(options))
+	t.Cleanup(server.Close)
+	return
+}
+
+func TestServerRemoteAPI(t *testing.T) {
+	server, _, _ := setupServer(t, apiserver.Options{Index: true, Gzip: true}, "")
+
+	remoteAPI, err := api.NewRemoteAPI(afero.NewMemMapFs(), server.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the plain and the indexed API see the same metadata
+	for _, toolctlAPI := range []api.ToolctlAPI{remoteAPI, api.NewIndexedAPI(remoteAPI)} {
+		meta, err := api.GetToolPlatformVersionMeta(
+			toolctlAPI, api.Tool{Name: "toolctl-test-tool", OS: "linux", Arch: "amd64", Version: "1.0.0"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.SHA256 == "" {
+			t.Errorf("GetToolPlatformVersionMeta() = %+v, want a SHA256", meta)
+		}
+	}
+
+	found, _, err := remoteAPI.GetContents("toolctl-test-tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("GetContents() of a directory should not find anything")
+	}
+}
+
+func TestServerETagAndGzip(t *testing.T) {
+	server, _, _ := setupServer(t, apiserver.Options{Gzip: true}, "")
+
+	// Disable the transparent decompression to see the raw responses
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	tests := []struct {
+		name                string
+		acceptEncoding      string
+		wantContentEncoding string
+	}{
+		{name: "identity"},
+		{name: "gzip", acceptEncoding: "br, gzip", wantContentEncoding: "gzip"},
+		{name: "gzip not acceptable", acceptEncoding: "gzip;q=0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/meta.yaml", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("Content-Encoding"); got != tt.wantContentEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantContentEncoding)
+			}
+
+			etag := resp.Header.Get("ETag")
+			if etag == "" {
+				t.Fatal("no ETag")
+			}
+			req.Header.Set("If-None-Match", etag)
+			resp, err = client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNotModified {
+				t.Errorf("conditional request status = %d, want %d", resp.StatusCode, http.StatusNotModified)
+			}
+		})
+	}
+}
+
+func TestServerDownloads(t *testing.T) {
+	tests := []struct {
+		name                 string
+		sha256Override       string
+		path                 string
+		wantStatus           int
+		wantBody             string
+		wantUpstreamRequests int
+	}{
+		{
+			name:                 "mirrored download",
+			path:                 "/releases/1.0.0/toolctl-test-tool",
+			wantStatus:           http.StatusOK,
+			wantBody:             "toolctl test tool",
+			wantUpstreamRequests: 1,
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:       "unknown download",
+			path:       "/releases/2.0.0/toolctl-test-tool",
+			wantStatus: http.StatusNotFound,
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:                 "checksum mismatch",
+			sha256Override:       fmt.Sprintf("%064d", 0),
+			path:                 "/releases/1.0.0/toolctl-test-tool",
+			wantStatus:           http.StatusBadGateway,
+			wantBody:             "SHA256 hash mismatch",
+			wantUpstreamRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, upstreamHost, upstreamRequests := setupServer(
+				t, apiserver.Options{DownloadsDir: "/downloads"}, tt.sha256Override,
+			)
+			mirrorURL := server.URL + apiserver.DownloadsPath + upstreamHost + tt.path
+
+			// Request the download twice, as verified downloads are only
+			// fetched from upstream once
+			for range 2 {
+				resp, err := http.Get(mirrorURL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+				if tt.wantBody != "" && !bytes.Contains(body, []byte(tt.wantBody)) {
+					t.Errorf("body = %q, want %q", body, tt.wantBody)
+				}
+			}
+
+			if *upstreamRequests != tt.wantUpstreamRequests {
+				t.Errorf("upstream requests = %d, want %d", *upstreamRequests, tt.wantUpstreamRequests)
+			}
+		})
+	}
+}
+
+func TestServerConcurrentDownloads(t *testing.T) {
+	slowStarted := make(chan struct{})
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	releaseUpstream := func() { releaseOnce.Do(func() { close(release) }) }
+	var slowRequests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/releases/1.0.0/toolctl-test-tool" && slowRequests.Add(1) == 1 {
+			close(slowStarted)
+			<-release
+		}
+		_, _ = w.Write([]byte("toolctl test tool " + path.Base(path.Dir(r.URL.Path))))
+	}))
+	defer upstream.Close()
+	// Unblock the upstream server even if the test fails, so that it can shut
+	// down
+	defer releaseUpstream()
+
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"meta.yaml":                               "tools:\n  - toolctl-test-tool\n",
+		"toolctl-test-tool/meta.yaml":             "description: toolctl test tool\n",
+		"toolctl-test-tool/linux-amd64/meta.yaml": "version:\n  earliest: 1.0.0\n  latest: 1.1.0\n",
+	}
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		files["toolctl-test-tool/linux-amd64/"+version+".yaml"] = fmt.Sprintf(
+			"url: %s/releases/%s/toolctl-test-tool\nsha256: %x\n",
+			upstream.URL, version, sha256.Sum256([]byte("toolctl test tool "+version)),
+		)
+	}
+	for filePath, contents := range files {
+		err := afero.WriteFile(fs, path.Join(basePath, filePath), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(apiserver.New(apiserver.Options{
+		FS: fs, BasePath: basePath, DownloadsDir: "/downloads",
+	}))
+	defer server.Close()
+	mirrorURL := server.URL + apiserver.DownloadsPath + strings.TrimPrefix(upstream.URL, "http://")
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	get := func(path string) (status int, err error) {
+		resp, err := client.Get(mirrorURL + path)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	// Concurrent requests for the same download share a single fetch
+	var wg sync.WaitGroup
+	statuses := make([]int, 3)
+	errs := make([]error, 3)
+	for i := range statuses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i], errs[i] = get("/releases/1.0.0/toolctl-test-tool")
+		}()
+	}
+	<-slowStarted
+
+	// Other downloads don't have to wait for it
+	status, err := get("/releases/1.1.0/toolctl-test-tool")
+	if err != nil {
+		releaseUpstream()
+		wg.Wait()
+		t.Fatal(err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want %d", status, http.StatusOK)
+	}
+
+	releaseUpstream()
+	wg.Wait()
+	for i := range statuses {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if statuses[i] != http.StatusOK {
+			t.Errorf("status = %d, want %d", statuses[i], http.StatusOK)
+		}
+	}
+	if slowRequests.Load() != 1 {
+		t.Errorf("upstream requests = %d, want 1", slowRequests.Load())
+	}
+}
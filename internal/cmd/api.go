@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/toolctl/toolctl/internal/api"
 )
 
 func newAPICmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
@@ -15,7 +17,26 @@ func newAPICmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
 	}
 
 	apiCmd.AddCommand(newDiscoverCmd(toolctlWriter, localAPIFS))
+	apiCmd.AddCommand(newServeCmd(toolctlWriter, localAPIFS))
 	apiCmd.AddCommand(newSyncCmd(localAPIFS))
+	apiCmd.AddCommand(newYankCmd(toolctlWriter, localAPIFS))
 
 	return apiCmd
 }
+
+// newWritableAPI returns the API that an api command writes to. It is the local
+// API, unless another registry is passed explicitly with --registry. If
+// requireLocal is true, registries that aren't stored on the local file system
+// are rejected, as the command works on the files directly.
+func newWritableAPI(
+	localAPIFS afero.Fs, cmd *cobra.Command, requireLocal bool,
+) (toolctlAPI api.ToolctlAPI, err error) {
+	toolctlAPI, err = api.NewWritable(localAPIFS, cmd)
+	if err != nil {
+		return
+	}
+	if requireLocal && toolctlAPI.LocalAPIBasePath() == "" {
+		err = fmt.Errorf("api %s requires a local registry", cmd.Name())
+	}
+	return
+}
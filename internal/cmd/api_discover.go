@@ -2,20 +2,25 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"os"
+	"path"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
 
-	"github.com/Masterminds/semver"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/httpclient"
+	"github.com/toolctl/toolctl/internal/verify"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -44,8 +49,7 @@ func newDiscoverCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command
 
 func newRunDiscover(toolctlWriter io.Writer, localAPIFS afero.Fs) func(cmd *cobra.Command, args []string) (err error) {
 	return func(cmd *cobra.Command, args []string) (err error) {
-		// Needs to run with the local API because we need write access
-		toolctlAPI, err := api.New(localAPIFS, cmd, api.Local)
+		toolctlAPI, err := newWritableAPI(localAPIFS, cmd, false)
 		if err != nil {
 			return err
 		}
@@ -102,7 +106,12 @@ func discover(
 		return
 	}
 
-	var version *semver.Version
+	scheme, err := api.GetVersionScheme(toolMeta)
+	if err != nil {
+		return
+	}
+
+	var version *api.Version
 	if tool.Version != "" {
 		if tool.Version == "earliest" {
 			var toolPlatformMeta api.ToolPlatformMeta
@@ -110,15 +119,18 @@ func discover(
 			if err != nil {
 				return
 			}
-			version = semver.MustParse(toolPlatformMeta.Version.Earliest)
+			version, err = scheme.Parse(toolPlatformMeta.Version.Earliest)
+			if err != nil {
+				return
+			}
 		} else {
-			version, err = semver.NewVersion(tool.Version)
+			version, err = scheme.Parse(tool.Version)
 			if err != nil {
 				return
 			}
 		}
 	} else {
-		version, err = setInitialVersion(toolctlAPI, tool)
+		version, err = setInitialVersion(toolctlAPI, scheme, tool)
 		if err != nil {
 			return
 		}
@@ -183,9 +195,20 @@ func discover(
 		return
 	}
 
-	tool.Version = version.String()
+	// Parse the optional checksum URL template
+	var checksumURLTemplate *template.Template
+	if toolMeta.ChecksumURLTemplate != "" {
+		checksumURLTemplate, err = template.New("ChecksumURL").Funcs(funcMap).Parse(
+			toolMeta.ChecksumURLTemplate,
+		)
+		if err != nil {
+			return
+		}
+	}
+
 	err = discoverLoop(
-		toolctlWriter, toolctlAPI, toolMeta, tool, downloadURLTemplate,
+		toolctlWriter, toolctlAPI, toolMeta, scheme, tool, version,
+		downloadURLTemplate, checksumURLTemplate,
 	)
 
 	return
@@ -193,14 +216,18 @@ func discover(
 
 func discoverLoop(
 	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta,
-	tool api.Tool, downloadURLTemplate *template.Template,
+	scheme api.VersionScheme, tool api.Tool, version *api.Version,
+	downloadURLTemplate *template.Template, checksumURLTemplate *template.Template,
 ) (err error) {
+	// Components are incremented starting with the least significant one, and
+	// moving on to more significant ones after repeated misses
+	leastSignificantComponent := len(scheme.Components()) - 1
+
 	var (
-		componentToIncrement = "patch"
+		componentToIncrement = leastSignificantComponent
 		ignoredVersions      = getIgnoredVersionsMap(toolMeta)
 		missCounter          int
 		url                  string
-		version              = semver.MustParse(tool.Version)
 	)
 
 	for {
@@ -215,12 +242,12 @@ func discoverLoop(
 				tool.Name, tool.OS, tool.Arch, tool.Version,
 			)
 
-			componentToIncrement = "patch"
+			componentToIncrement = leastSignificantComponent
 			missCounter = 0
 			skipSleep = true
 
 			version, err = incrementAndSleep(
-				version, componentToIncrement, url, skipSleep,
+				scheme, version, componentToIncrement, url, skipSleep,
 			)
 			if err != nil {
 				return
@@ -237,43 +264,33 @@ func discoverLoop(
 			}
 
 			// We don't have the version yet, so we need to check if it's available
-			var b bytes.Buffer
-			err = downloadURLTemplate.Execute(&b, tool)
-			if err != nil {
-				return
-			}
-			url = b.String()
-
-			fmt.Fprintf(toolctlWriter, "%s %s/%s v%s ...\n",
-				tool.Name, tool.OS, tool.Arch, tool.Version,
+			url, statusCode, err = probeVersion(
+				toolctlWriter, toolctlAPI, toolMeta, tool,
+				downloadURLTemplate, checksumURLTemplate,
 			)
-			fmt.Fprintf(toolctlWriter, "URL: %s\n", url)
-
-			statusCode, err = getStatusCode(url)
 			if err != nil {
 				return
 			}
 
 			if statusCode == http.StatusOK {
-				err = addNewVersion(toolctlWriter, toolctlAPI, toolMeta, tool, url)
+				componentToIncrement = leastSignificantComponent
+			} else {
+				// The version may not be released yet, but prereleases may be
+				err = discoverPrereleases(
+					toolctlWriter, toolctlAPI, toolMeta, tool,
+					downloadURLTemplate, checksumURLTemplate, ignoredVersions,
+				)
 				if err != nil {
 					return
 				}
-				componentToIncrement = "patch"
-			} else {
-				fmt.Fprintf(toolctlWriter, "HTTP status: %d\n", statusCode)
 
 				missCounter++
 
 				if missCounter > 1 {
-					switch componentToIncrement {
-					case "patch":
-						componentToIncrement = "minor"
-					case "minor":
-						componentToIncrement = "major"
-					case "major":
+					if componentToIncrement == 0 {
 						return
 					}
+					componentToIncrement--
 					missCounter = 0
 				}
 			}
@@ -281,13 +298,13 @@ func discoverLoop(
 			fmt.Fprintf(toolctlWriter, "%s %s/%s v%s already added\n",
 				tool.Name, tool.OS, tool.Arch, tool.Version,
 			)
-			componentToIncrement = "patch"
+			componentToIncrement = leastSignificantComponent
 			missCounter = 0
 			skipSleep = true
 		}
 
 		version, err = incrementAndSleep(
-			version, componentToIncrement, url, skipSleep,
+			scheme, version, componentToIncrement, url, skipSleep,
 		)
 		if err != nil {
 			return
@@ -295,6 +312,103 @@ func discoverLoop(
 	}
 }
 
+// probeVersion checks if a version of a tool is available for download and
+// adds it to the API if it is.
+func probeVersion(
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta,
+	tool api.Tool, downloadURLTemplate *template.Template,
+	checksumURLTemplate *template.Template,
+) (url string, statusCode int, err error) {
+	var b bytes.Buffer
+	err = downloadURLTemplate.Execute(&b, tool)
+	if err != nil {
+		return
+	}
+	url = b.String()
+
+	fmt.Fprintf(toolctlWriter, "%s %s/%s v%s ...\n",
+		tool.Name, tool.OS, tool.Arch, tool.Version,
+	)
+	fmt.Fprintf(toolctlWriter, "URL: %s\n", httpclient.RedactURL(url))
+
+	statusCode, lastModified, err := headURL(url)
+	if err != nil {
+		return
+	}
+
+	if statusCode != http.StatusOK {
+		fmt.Fprintf(toolctlWriter, "HTTP status: %d\n", statusCode)
+		return
+	}
+
+	var checksumURL string
+	if checksumURLTemplate != nil {
+		b.Reset()
+		err = checksumURLTemplate.Execute(&b, tool)
+		if err != nil {
+			return
+		}
+		checksumURL = b.String()
+	}
+
+	err = addNewVersion(
+		toolctlWriter, toolctlAPI, toolMeta, tool, url, checksumURL, lastModified,
+	)
+	return
+}
+
+// discoverPrereleases looks for prereleases of a stable version in the
+// prerelease channels of a tool. Prereleases are expected to be numbered,
+// e.g. 1.2.0-rc.1, 1.2.0-rc.2 and so on.
+func discoverPrereleases(
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta,
+	tool api.Tool, downloadURLTemplate *template.Template,
+	checksumURLTemplate *template.Template, ignoredVersions map[string]struct{},
+) (err error) {
+	stableVersion := tool.Version
+
+	for _, channel := range toolMeta.PrereleaseChannels {
+		for n := 1; ; n++ {
+			tool.Version = fmt.Sprintf("%s-%s.%d", stableVersion, channel, n)
+
+			if _, exists := ignoredVersions[tool.Version]; exists {
+				fmt.Fprintf(toolctlWriter, "%s %s/%s v%s ignored\n",
+					tool.Name, tool.OS, tool.Arch, tool.Version,
+				)
+				continue
+			}
+
+			_, err = api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+			if err == nil {
+				fmt.Fprintf(toolctlWriter, "%s %s/%s v%s already added\n",
+					tool.Name, tool.OS, tool.Arch, tool.Version,
+				)
+				continue
+			}
+			if !errors.Is(err, api.NotFoundError{}) {
+				return
+			}
+
+			var url string
+			var statusCode int
+			url, statusCode, err = probeVersion(
+				toolctlWriter, toolctlAPI, toolMeta, tool,
+				downloadURLTemplate, checksumURLTemplate,
+			)
+			if err != nil {
+				return
+			}
+			if statusCode != http.StatusOK {
+				break
+			}
+
+			sleepBetweenRequests(url)
+		}
+	}
+
+	return
+}
+
 func getIgnoredVersionsMap(toolMeta api.ToolMeta) map[string]struct{} {
 	ignoredVersions := make(map[string]struct{}, len(toolMeta.IgnoredVersions))
 	for _, ignoredVersion := range toolMeta.IgnoredVersions {
@@ -304,27 +418,36 @@ func getIgnoredVersionsMap(toolMeta api.ToolMeta) map[string]struct{} {
 }
 
 func incrementAndSleep(
-	versionToIncrement *semver.Version, componentToIncrement string,
-	url string, skipSleep bool,
-) (version *semver.Version, err error) {
-	version, err = incrementVersion(versionToIncrement, componentToIncrement)
+	scheme api.VersionScheme, versionToIncrement *api.Version,
+	componentToIncrement int, url string, skipSleep bool,
+) (version *api.Version, err error) {
+	version, err = incrementVersion(scheme, versionToIncrement, componentToIncrement)
 	if err != nil {
 		return
 	}
 
-	if skipSleep || strings.HasPrefix(url, "http://127.0.0.1:") {
-		return
+	if !skipSleep {
+		sleepBetweenRequests(url)
 	}
 
-	time.Sleep(500 * time.Millisecond)
-
 	return
 }
 
+// sleepBetweenRequests avoids hammering download servers, except for the
+// local servers used in tests.
+func sleepBetweenRequests(url string) {
+	if strings.HasPrefix(url, "http://127.0.0.1:") {
+		return
+	}
+	time.Sleep(500 * time.Millisecond)
+}
+
 // addNewVersion adds a new version of a tool to the local API.
+// If a checksum URL is given, the SHA256 of the download has to match the
+// upstream checksum file.
 func addNewVersion(
 	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta,
-	tool api.Tool, url string,
+	tool api.Tool, url string, checksumURL string, releaseDate time.Time,
 ) (err error) {
 	tempDir, err := os.MkdirTemp("", "toolctl-*")
 	if err != nil {
@@ -337,24 +460,43 @@ func addNewVersion(
 		return
 	}
 
-	// Check the version, if we can run the tool binary
-	if tool.OS == runtime.GOOS && tool.Arch == runtime.GOARCH {
-		// Extract the downloaded tool
-		var extractedToolPath string
-		extractedToolPath, err = extractDownloadedTool(tool, downloadedToolPath)
+	if checksumURL != "" {
+		fmt.Fprintf(toolctlWriter, "Checksum URL: %s\n", httpclient.RedactURL(checksumURL))
+		err = checkUpstreamChecksum(
+			checksumURL, checksumFileName(url, checksumURL), sha256, tempDir,
+		)
 		if err != nil {
 			return
 		}
+	}
 
-		// Check the version
-		var toolBinaryVersion *semver.Version
+	// Extract the downloaded tool
+	extractedToolPath, err := extractDownloadedTool(tool, downloadedToolPath)
+	if err != nil {
+		return
+	}
+
+	// Make sure the binary was built for the platform
+	err = checkBinaryPlatform(tool, extractedToolPath)
+	if err != nil {
+		return
+	}
+
+	// Check the version, if we can run the tool binary
+	if tool.OS == runtime.GOOS && tool.Arch == runtime.GOARCH {
+		var toolBinaryVersion *api.Version
 		toolBinaryVersion, err = getToolBinaryVersion(
-			extractedToolPath, toolMeta.VersionArgs,
+			extractedToolPath, toolMeta,
 		)
 		if err != nil {
 			return
 		}
-		if !toolBinaryVersion.Equal(semver.MustParse(tool.Version)) {
+		var version *api.Version
+		version, err = api.ParseVersion(toolMeta, tool.Version)
+		if err != nil {
+			return
+		}
+		if !toolBinaryVersion.Equal(version) {
 			err = fmt.Errorf(
 				"version mismatch: expected %s, got %s",
 				tool.Version, toolBinaryVersion,
@@ -365,10 +507,50 @@ func addNewVersion(
 
 	fmt.Fprintln(toolctlWriter, "SHA256:", sha256)
 
+	// Calculate the additional digests that are configured
+	digests, err := calculateDigests(
+		downloadedToolPath, viper.GetStringSlice("DigestAlgorithms"),
+	)
+	if err != nil {
+		return
+	}
+	for _, algorithm := range verify.DigestAlgorithms() {
+		if digest, ok := digests[algorithm]; ok && algorithm != "sha256" {
+			fmt.Fprintf(toolctlWriter, "%s: %s\n", strings.ToUpper(algorithm), digest)
+		}
+	}
+
+	// Calculate the SHA256 of the binary, to be able to verify installations
+	binarySHA256, err := calculateFileSHA256(extractedToolPath)
+	if err != nil {
+		return
+	}
+
+	// Fall back to the GitHub release date if the download server doesn't
+	// report when the file was last modified. The release date is only used to
+	// hold back fresh releases, so it is left empty if it can't be determined.
+	if releaseDate.IsZero() {
+		var releaseDateErr error
+		releaseDate, releaseDateErr = getGitHubReleaseDate(url)
+		if releaseDateErr != nil {
+			fmt.Fprintf(toolctlWriter, "Release date: unknown (%v)\n", releaseDateErr)
+		}
+	}
+
 	// Save the tool platform version metadata
 	toolPlatformVersionMeta := api.ToolPlatformVersionMeta{
-		URL:    url,
-		SHA256: sha256,
+		URL:          url,
+		SHA256:       sha256,
+		Digests:      digests,
+		BinarySHA256: binarySHA256,
+		ReleaseDate:  releaseDate.UTC(),
+	}
+	version, err := api.ParseVersion(toolMeta, tool.Version)
+	if err != nil {
+		return
+	}
+	if channel := api.VersionChannel(version); channel != api.StableChannel {
+		toolPlatformVersionMeta.Channel = channel
 	}
 	err = api.SaveToolPlatformVersionMeta(toolctlAPI, tool, toolPlatformVersionMeta)
 	if err != nil {
@@ -376,15 +558,86 @@ func addNewVersion(
 	}
 
 	// Update the tool platform metadata
-	err = updateToolPlatformMeta(toolctlAPI, tool)
+	err = updateToolPlatformMeta(toolctlAPI, toolMeta, tool)
 	if err != nil {
 		return
 	}
 
+	err = api.UpdateIndex(toolctlAPI, tool)
 	return
 }
 
-func updateToolPlatformMeta(toolctlAPI api.ToolctlAPI, tool api.Tool) (err error) {
+// calculateDigests calculates the digests of a downloaded tool for the given
+// algorithms. It returns nil if no algorithms are given.
+func calculateDigests(
+	downloadedToolPath string, algorithms []string,
+) (digests map[string]string, err error) {
+	if len(algorithms) == 0 {
+		return
+	}
+
+	downloadedTool, err := os.Open(downloadedToolPath)
+	if err != nil {
+		return
+	}
+	defer downloadedTool.Close()
+
+	digests, err = verify.Digests(downloadedTool, algorithms)
+	return
+}
+
+// checksumFileName returns the name of a download in the checksum file: its
+// path relative to the checksum file if it is in the same directory or below,
+// otherwise its base name.
+func checksumFileName(url string, checksumURL string) string {
+	checksumDir := checksumURL[:strings.LastIndex(checksumURL, "/")+1]
+	if name, found := strings.CutPrefix(url, checksumDir); found && checksumDir != "" {
+		return name
+	}
+	return path.Base(url)
+}
+
+// checkUpstreamChecksum downloads the upstream checksum file and makes sure
+// that it contains the given SHA256 for the given file name.
+func checkUpstreamChecksum(
+	checksumURL string, fileName string, sha256 string, dir string,
+) (err error) {
+	checksumDir, err := os.MkdirTemp(dir, "checksums-*")
+	if err != nil {
+		return
+	}
+	checksumFilePath, _, err := downloadURL(checksumURL, checksumDir)
+	if err != nil {
+		return fmt.Errorf("failed to download checksum file: %w", err)
+	}
+	checksums, err := os.ReadFile(checksumFilePath)
+	if err != nil {
+		return
+	}
+
+	upstreamSHA256, err := verify.ChecksumForFile(checksums, fileName)
+	if err != nil {
+		return
+	}
+	if upstreamSHA256 != sha256 {
+		err = fmt.Errorf(
+			"SHA256 hash mismatch with upstream checksum file, wanted %s, got %s",
+			upstreamSHA256, sha256,
+		)
+		return
+	}
+
+	return
+}
+
+func updateToolPlatformMeta(
+	toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta, tool api.Tool,
+) (err error) {
+	scheme, err := api.GetVersionScheme(toolMeta)
+	if err != nil {
+		return
+	}
+
 	var toolPlatformMeta api.ToolPlatformMeta
 	toolPlatformMeta, err = api.GetToolPlatformMeta(toolctlAPI, tool)
 	if err != nil {
@@ -392,38 +645,26 @@ func updateToolPlatformMeta(toolctlAPI api.ToolctlAPI, tool api.Tool) (err error
 			return
 		}
 
-		toolPlatformMeta = api.ToolPlatformMeta{
-			Version: api.ToolPlatformMetaVersion{
-				Earliest: "42.0.0",
-				Latest:   "0.0.0",
-			},
-		}
+		toolPlatformMeta = api.ToolPlatformMeta{}
 		err = api.SaveToolPlatformMeta(toolctlAPI, tool, toolPlatformMeta)
 		if err != nil {
 			return
 		}
 	}
 
-	var earliestVersion *semver.Version
-	earliestVersion, err = semver.NewVersion(toolPlatformMeta.Version.Earliest)
+	version, err := scheme.Parse(tool.Version)
 	if err != nil {
-		earliestVersion = semver.MustParse("42.0.0")
-	}
-
-	version := semver.MustParse(tool.Version)
-	if version.LessThan(earliestVersion) {
-		toolPlatformMeta.Version.Earliest = version.String()
+		return
 	}
-
-	var latestVersion *semver.Version
-	latestVersion, err = semver.NewVersion(toolPlatformMeta.Version.Latest)
+	toolPlatformVersionMeta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
 	if err != nil {
-		latestVersion = semver.MustParse("0.0.0")
-	}
-	if version.GreaterThan(latestVersion) {
-		toolPlatformMeta.Version.Latest = version.String()
+		return
 	}
 
+	addVersionToToolPlatformMeta(
+		&toolPlatformMeta, scheme, version, toolPlatformVersionMeta.Yanked,
+	)
+
 	err = api.SaveToolPlatformMeta(toolctlAPI, tool, toolPlatformMeta)
 	if err != nil {
 		return
@@ -432,15 +673,69 @@ func updateToolPlatformMeta(toolctlAPI api.ToolctlAPI, tool api.Tool) (err error
 	return
 }
 
-func setInitialVersion(toolctlAPI api.ToolctlAPI, noa api.Tool) (version *semver.Version, err error) {
+// addVersionToToolPlatformMeta updates the earliest and latest versions of a
+// tool platform metadata with a version. Yanked versions are never the latest
+// version of the tool or of a release channel.
+func addVersionToToolPlatformMeta(
+	toolPlatformMeta *api.ToolPlatformMeta, scheme api.VersionScheme,
+	version *api.Version, yanked bool,
+) {
+	channel := api.VersionChannel(version)
+
+	// Versions that can't be parsed, e.g. because there are none yet, are
+	// replaced
+	if channel == api.StableChannel {
+		earliestVersion, earliestErr := scheme.Parse(toolPlatformMeta.Version.Earliest)
+		if earliestErr != nil || version.LessThan(earliestVersion) {
+			toolPlatformMeta.Version.Earliest = version.String()
+		}
+	}
+
+	if yanked {
+		return
+	}
+
+	// Prereleases are tracked per channel, so that they don't end up in the
+	// stable versions
+	if channel != api.StableChannel {
+		channelVersion, channelErr := scheme.Parse(
+			toolPlatformMeta.Version.Channels[channel],
+		)
+		if channelErr != nil || version.GreaterThan(channelVersion) {
+			if toolPlatformMeta.Version.Channels == nil {
+				toolPlatformMeta.Version.Channels = map[string]string{}
+			}
+			toolPlatformMeta.Version.Channels[channel] = version.String()
+		}
+		return
+	}
+
+	latestVersion, latestErr := scheme.Parse(toolPlatformMeta.Version.Latest)
+	if latestErr != nil || version.GreaterThan(latestVersion) {
+		toolPlatformMeta.Version.Latest = version.String()
+	}
+}
+
+func setInitialVersion(
+	toolctlAPI api.ToolctlAPI, scheme api.VersionScheme, noa api.Tool,
+) (version *api.Version, err error) {
 	version, err = api.GetLatestVersion(toolctlAPI, noa)
 	if err != nil {
 		if !errors.Is(err, api.NotFoundError{}) {
 			return
 		}
-		version = semver.MustParse("0.0.0")
+		version = scheme.Initial()
+		if version == nil {
+			err = fmt.Errorf(
+				"%s has no versions yet, specify the version to start from, e.g.:\n  toolctl api discover %s@VERSION",
+				noa.Name, noa.Name,
+			)
+			return
+		}
 	}
-	version, err = incrementVersion(version, "patch")
+	version, err = incrementVersion(
+		scheme, version, len(scheme.Components())-1,
+	)
 	if err != nil {
 		return
 	}
@@ -448,36 +743,97 @@ func setInitialVersion(toolctlAPI api.ToolctlAPI, noa api.Tool) (version *semver
 	return
 }
 
-func getStatusCode(url string) (statusCode int, err error) {
-	resp, err := http.Head(url)
+// headURL returns the status code of a URL, and when it was last modified if
+// the server reports it.
+func headURL(url string) (statusCode int, lastModified time.Time, err error) {
+	resp, err := httpclient.Head(url)
 	if err != nil {
 		return
 	}
 	statusCode = resp.StatusCode
+	lastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
 	return
 }
 
-func incrementVersion(version *semver.Version, component string) (*semver.Version, error) {
-	var incrementedVersion semver.Version
+// githubReleaseDownloadRegex matches the download URLs of GitHub release
+// assets, capturing the owner, repo and tag.
+var githubReleaseDownloadRegex = regexp.MustCompile(
+	`^https://github\.com/([^/]+)/([^/]+)/releases/download/([^/]+)/`,
+)
 
-	switch component {
-	case "major":
-		if version.Patch() == 0 {
-			incrementedVersion = version.IncPatch()
-		} else {
-			incrementedVersion = version.IncMajor()
-		}
-	case "minor":
-		if version.Patch() == 0 {
-			incrementedVersion = version.IncPatch()
-		} else {
-			incrementedVersion = version.IncMinor()
-		}
-	case "patch":
-		incrementedVersion = version.IncPatch()
-	default:
-		return nil, fmt.Errorf("invalid version component: %s", component)
+// githubAPIHost is the host of the GitHub REST API. Without a token, it only
+// allows 60 requests per hour, so GITHUB_TOKEN is sent if no credentials are
+// configured for it.
+const githubAPIHost = "api.github.com"
+
+// githubAPIBaseURL is the base URL of the GitHub REST API.
+const githubAPIBaseURL = "https://" + githubAPIHost
+
+// githubRelease is the result of looking up a GitHub release.
+type githubRelease struct {
+	publishedAt time.Time
+	err         error
+}
+
+// githubReleases caches the GitHub releases by owner, repo and tag, as the
+// downloads of all platforms of a version belong to the same release.
+var githubReleases = map[string]githubRelease{}
+
+// getGitHubReleaseDate returns the publication date of the GitHub release that
+// a download URL belongs to. The zero time is returned for other URLs.
+func getGitHubReleaseDate(url string) (releaseDate time.Time, err error) {
+	match := githubReleaseDownloadRegex.FindStringSubmatch(url)
+	if match == nil {
+		return
+	}
+
+	key := strings.Join(match[1:], "/")
+	release, ok := githubReleases[key]
+	if !ok {
+		release.publishedAt, release.err = fetchGitHubReleaseDate(match[1], match[2], match[3])
+		githubReleases[key] = release
+	}
+	return release.publishedAt, release.err
+}
+
+// fetchGitHubReleaseDate fetches the publication date of a GitHub release.
+func fetchGitHubReleaseDate(owner, repo, tag string) (releaseDate time.Time, err error) {
+	resp, err := httpclient.Get(fmt.Sprintf(
+		"%s/repos/%s/%s/releases/tags/%s", githubAPIBaseURL, owner, repo, tag,
+	))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf(
+			"failed to get GitHub release %s: unexpected status code: %d", tag, resp.StatusCode,
+		)
+		return
+	}
+
+	var release struct {
+		PublishedAt time.Time `json:"published_at"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&release)
+	if err != nil {
+		return
+	}
+	releaseDate = release.PublishedAt
+	return
+}
+
+func incrementVersion(
+	scheme api.VersionScheme, version *api.Version, component int,
+) (*api.Version, error) {
+	// Before incrementing a more significant component, try the version after
+	// a fresh release, e.g. 1.3.1 after 1.3.0
+	leastSignificantComponent := len(scheme.Components()) - 1
+	segments := version.Segments()
+	if component < leastSignificantComponent &&
+		(len(segments) <= leastSignificantComponent || segments[leastSignificantComponent] == 0) {
+		component = leastSignificantComponent
 	}
 
-	return &incrementedVersion, nil
+	return scheme.Increment(version, component)
 }
@@ -3,13 +3,17 @@ package cmd_test
 import (
 	"bytes"
 	"fmt"
+	"io/fs"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/cmd"
+	"github.com/toolctl/toolctl/internal/s3/s3test"
 )
 
 func TestAPIDiscoverCmd(t *testing.T) {
@@ -66,7 +70,9 @@ Flags:
       --os strings     comma-separated list of operating systems (default [darwin,linux])
 
 Global Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
 `,
 		},
 		// -------------------------------------------------------------------------
@@ -294,6 +300,263 @@ URL: .+/v0.1.1/toolctl-test-tool-template-func-v0.1.1.linux.arm64`,
 			wantOutRegex: `v0.1.1 ignored`,
 		},
 		// -------------------------------------------------------------------------
+		{
+			name: "supported tool that is not an executable for the platform",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.1.1",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+					shellScript:          true,
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			wantErr: true,
+			wantOutRegex: `(?s)URL: .+/0.1.1/toolctl-test-tool.tar.gz
+Error: toolctl-test-tool is not an executable for ` + runtime.GOOS + "/" + runtime.GOARCH + `: not an? (ELF|Mach-O) file
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with matching upstream checksum",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.0",
+					tarGz:        true,
+					checksumFile: true,
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.1.1",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+					checksumFile:         true,
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			wantOutRegex: `(?s)URL: .+/0.1.1/toolctl-test-tool.tar.gz
+Checksum URL: .+/0.1.1/checksums.txt
+SHA256: [0-9a-f]{64}
+`,
+			wantFiles: []APIFile{
+				{
+					Path: fmt.Sprintf(
+						"toolctl-test-tool/%s-%s/0.1.1.yaml", runtime.GOOS, runtime.GOARCH,
+					),
+				},
+			},
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with mismatching upstream checksum",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.0",
+					tarGz:        true,
+					checksumFile: true,
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.1.1",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+					checksumFile:         true,
+					checksumFileMismatch: true,
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			wantErr: true,
+			wantOutRegex: `(?s)Checksum URL: .+/0.1.1/checksums.txt
+Error: SHA256 hash mismatch with upstream checksum file, wanted 0{64}, got [0-9a-f]{64}
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with configured digest algorithms",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.1.1",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			config: map[string]any{
+				"DigestAlgorithms": []string{"sha512", "blake2b"},
+			},
+			wantOutRegex: `(?s)URL: .+/0.1.1/toolctl-test-tool.tar.gz
+SHA256: [0-9a-f]{64}
+BLAKE2B: [0-9a-f]{128}
+SHA512: [0-9a-f]{128}
+`,
+			wantFiles: []APIFile{
+				{
+					Path: fmt.Sprintf(
+						"toolctl-test-tool/%s-%s/0.1.1.yaml", runtime.GOOS, runtime.GOARCH,
+					),
+					Contents: `(?s)digests:
+  blake2b: [0-9a-f]{128}
+  sha512: [0-9a-f]{128}
+`,
+				},
+			},
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with prerelease channels",
+			supportedTools: []supportedTool{
+				{
+					name:               "toolctl-test-tool",
+					version:            "0.1.0",
+					tarGz:              true,
+					prereleaseChannels: []string{"beta"},
+					versionRegex:       `v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`,
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.2.0-beta.1",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+					prereleaseChannels:   []string{"beta"},
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.2.0-beta.2",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+					prereleaseChannels:   []string{"beta"},
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			wantOutRegex: `(?s)v0.2.0 ...
+URL: .+/0.2.0/toolctl-test-tool.tar.gz
+HTTP status: 404
+.+ v0.2.0-beta.1 ...
+URL: .+/0.2.0-beta.1/toolctl-test-tool.tar.gz
+SHA256: [0-9a-f]{64}
+.+ v0.2.0-beta.2 ...
+URL: .+/0.2.0-beta.2/toolctl-test-tool.tar.gz
+SHA256: [0-9a-f]{64}
+.+ v0.2.0-beta.3 ...
+URL: .+/0.2.0-beta.3/toolctl-test-tool.tar.gz
+HTTP status: 404
+`,
+			wantFiles: []APIFile{
+				{
+					Path: fmt.Sprintf(
+						"toolctl-test-tool/%s-%s/0.2.0-beta.2.yaml", runtime.GOOS, runtime.GOARCH,
+					),
+					Contents: `(?m)^channel: beta$`,
+				},
+			},
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with release date",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "0.1.1",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			wantOutRegex: `(?s)URL: .+/0.1.1/toolctl-test-tool.tar.gz
+SHA256: [0-9a-f]{64}
+`,
+			wantFiles: []APIFile{
+				{
+					Path: fmt.Sprintf(
+						"toolctl-test-tool/%s-%s/0.1.1.yaml", runtime.GOOS, runtime.GOARCH,
+					),
+					Contents: `(?m)^releaseDate: \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`,
+				},
+			},
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with calendar versions",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "2024.11.0",
+					tarGz:         true,
+					versionScheme: "calver",
+				},
+				{
+					name:                 "toolctl-test-tool",
+					version:              "2025.01.0",
+					onlyOnDownloadServer: true,
+					tarGz:                true,
+					versionScheme:        "calver",
+				},
+			},
+			cliArgs: []string{
+				"toolctl-test-tool",
+				"--os", runtime.GOOS,
+				"--arch", runtime.GOARCH,
+			},
+			wantOutRegex: `(?s)v2024.11.1 ...
+.+v2024.11.2 ...
+.+v2024.12.0 ...
+.+v2024.12.1 ...
+.+v2025.01.0 ...
+URL: .+/2025.01.0/toolctl-test-tool.tar.gz
+SHA256: [0-9a-f]{64}
+`,
+			wantFiles: []APIFile{
+				{
+					Path: fmt.Sprintf(
+						"toolctl-test-tool/%s-%s/2025.01.0.yaml", runtime.GOOS, runtime.GOARCH,
+					),
+				},
+			},
+		},
+		// -------------------------------------------------------------------------
 		{
 			name:    "unsupported tool",
 			cliArgs: []string{"toolctl-unsupported-test-tool"},
@@ -322,6 +585,10 @@ URL: .+/v0.1.1/toolctl-test-tool-template-func-v0.1.1.linux.arm64`,
 			command := cmd.NewRootCmd(buf, localAPIFS)
 			command.SetArgs(append([]string{"api", "discover"}, tt.cliArgs...))
 			viper.Set("LocalAPIBasePath", localAPIBasePath)
+			for key, value := range tt.config {
+				viper.Set(key, value)
+				defer viper.Set(key, nil)
+			}
 
 			// Redirect Cobra output
 			command.SetOut(buf)
@@ -339,9 +606,13 @@ URL: .+/v0.1.1/toolctl-test-tool-template-func-v0.1.1.linux.arm64`,
 			checkWantOut(t, tt, buf)
 
 			for _, file := range tt.wantFiles {
-				_, err := localAPIFS.Stat(filepath.Join(localAPIBasePath, file.Path))
+				contents, err := afero.ReadFile(localAPIFS, filepath.Join(localAPIBasePath, file.Path))
 				if err != nil {
 					t.Errorf("Error checking file %s: %v", file.Path, err)
+					continue
+				}
+				if file.Contents != "" && !regexp.MustCompile(file.Contents).Match(contents) {
+					t.Errorf("File %s does not match %s:\n%s", file.Path, file.Contents, contents)
 				}
 			}
 		})
@@ -349,3 +620,69 @@ URL: .+/v0.1.1/toolctl-test-tool-template-func-v0.1.1.linux.arm64`,
 		downloadServer.Close()
 	}
 }
+
+func TestAPIDiscoverCmdS3(t *testing.T) {
+	localAPIFS, downloadServer, err := setupLocalAPI([]supportedTool{
+		{
+			name:    "toolctl-test-tool",
+			version: "0.1.0",
+			tarGz:   true,
+		},
+		{
+			name:                 "toolctl-test-tool",
+			version:              "0.1.1",
+			onlyOnDownloadServer: true,
+			tarGz:                true,
+		},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downloadServer.Close()
+
+	// The bucket starts out with the same files as the local API
+	s3Server := s3test.NewServer("AKIATOOLCTLTEST")
+	defer s3Server.Close()
+	err = afero.Walk(localAPIFS, localAPIBasePath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		contents, err := afero.ReadFile(localAPIFS, path)
+		if err != nil {
+			return err
+		}
+		s3Server.PutObject("toolctl", "api/v0/"+strings.TrimPrefix(path, localAPIBasePath+"/"), contents)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIATOOLCTLTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	buf := new(bytes.Buffer)
+	command := cmd.NewRootCmd(buf, afero.NewMemMapFs())
+	command.SetArgs([]string{
+		"api", "discover", "toolctl-test-tool",
+		"--os", runtime.GOOS,
+		"--arch", runtime.GOARCH,
+		"--registry", "s3://toolctl/api/v0?endpoint=" + s3Server.URL(),
+	})
+	command.SetOut(buf)
+	command.SetErr(buf)
+
+	err = command.Execute()
+	if err != nil {
+		t.Fatalf("Error = %v, output:\n%s", err, buf)
+	}
+
+	platform := runtime.GOOS + "-" + runtime.GOARCH
+	if _, found := s3Server.Object("toolctl", "api/v0/toolctl-test-tool/"+platform+"/0.1.1.yaml"); !found {
+		t.Errorf("api discover didn't publish 0.1.1 to the bucket, output:\n%s", buf)
+	}
+	platformMeta, _ := s3Server.Object("toolctl", "api/v0/toolctl-test-tool/"+platform+"/meta.yaml")
+	if !strings.Contains(string(platformMeta), "latest: 0.1.1") {
+		t.Errorf("api discover didn't update the latest version in the bucket:\n%s", platformMeta)
+	}
+}
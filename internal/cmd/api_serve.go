@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/toolctl/toolctl/internal/apiserver"
+)
+
+func newServeCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve [flags]",
+		Short: "Serve the local API over HTTP",
+		Example: `  # Serve the local API, to be used with --registry http://localhost:8080/
+  toolctl api serve
+
+  # Also mirror the downloads, to be used with a URL rewrite like
+  # {regex: "^https?://(.*)$", mirror: "http://localhost:8080/downloads/$1"}
+  toolctl api serve --downloads-dir /var/cache/toolctl/downloads`,
+		Args: cobra.NoArgs,
+		RunE: newRunServe(toolctlWriter, localAPIFS),
+	}
+
+	serveCmd.Flags().String("addr", "localhost:8080", "address to listen on")
+	serveCmd.Flags().String("downloads-dir", "", "directory to mirror the downloads of all tools in")
+	serveCmd.Flags().Bool("gzip", true, "compress responses for clients that accept it")
+	serveCmd.Flags().Bool("index", true, "generate the index instead of serving the file written by api sync")
+
+	return serveCmd
+}
+
+func newRunServe(
+	toolctlWriter io.Writer, localAPIFS afero.Fs,
+) func(cmd *cobra.Command, args []string) (err error) {
+	return func(cmd *cobra.Command, _ []string) (err error) {
+		// The files are served straight from the file system
+		toolctlAPI, err := newWritableAPI(localAPIFS, cmd, true)
+		if err != nil {
+			return
+		}
+
+		options := apiserver.Options{FS: localAPIFS, BasePath: toolctlAPI.LocalAPIBasePath()}
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return
+		}
+		options.DownloadsDir, err = cmd.Flags().GetString("downloads-dir")
+		if err != nil {
+			return
+		}
+		options.Gzip, err = cmd.Flags().GetBool("gzip")
+		if err != nil {
+			return
+		}
+		options.Index, err = cmd.Flags().GetBool("index")
+		if err != nil {
+			return
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+
+		fmt.Fprintf(toolctlWriter, "🌐 Serving %s at http://%s/\n", options.BasePath, listener.Addr())
+		if options.DownloadsDir != "" {
+			fmt.Fprintf(
+				toolctlWriter, "📦 Mirroring downloads in %s at http://%s%s\n",
+				options.DownloadsDir, listener.Addr(), apiserver.DownloadsPath,
+			)
+		}
+
+		return serve(cmd.Context(), listener, apiserver.New(options))
+	}
+}
+
+// serve serves HTTP requests until the context is done.
+func serve(ctx context.Context, listener net.Listener, handler http.Handler) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-served:
+		return
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/toolctl/toolctl/internal/cmd"
+)
+
+func TestAPIServeCmd(t *testing.T) {
+	tests := []test{
+		{
+			name:    "help flag",
+			cliArgs: []string{"--help"},
+			wantOut: `Serve the local API over HTTP
+
+Usage:
+  toolctl api serve [flags]
+
+Examples:
+  # Serve the local API, to be used with --registry http://localhost:8080/
+  toolctl api serve
+
+  # Also mirror the downloads, to be used with a URL rewrite like
+  # {regex: "^https?://(.*)$", mirror: "http://localhost:8080/downloads/$1"}
+  toolctl api serve --downloads-dir /var/cache/toolctl/downloads
+
+Flags:
+      --addr string            address to listen on (default "localhost:8080")
+      --downloads-dir string   directory to mirror the downloads of all tools in
+      --gzip                   compress responses for clients that accept it (default true)
+  -h, --help                   help for serve
+      --index                  generate the index instead of serving the file written by api sync (default true)
+
+Global Flags:
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "serve until canceled",
+			cliArgs: []string{"--addr", "127.0.0.1:0", "--downloads-dir", "/downloads"},
+			wantOutRegex: `^🌐 Serving /toolctl/tools/v0 at http://127.0.0.1:\d+/
+📦 Mirroring downloads in /downloads at http://127.0.0.1:\d+/downloads/
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:         "invalid address",
+			cliArgs:      []string{"--addr", "invalid"},
+			wantErr:      true,
+			wantOutRegex: `^Error: listen tcp: address invalid: missing port in address\n`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "remote registry",
+			cliArgs: []string{"--registry", "https://example.com/toolctl/api/v0"},
+			wantErr: true,
+			wantOutRegex: `^Error: api serve requires a local registry
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		localAPIFS, downloadServer, err := setupLocalAPI(tt.supportedTools, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			command := cmd.NewRootCmd(buf, localAPIFS)
+			command.SetArgs(append([]string{"api", "serve"}, tt.cliArgs...))
+			viper.Set("LocalAPIBasePath", localAPIBasePath)
+
+			// Redirect Cobra output
+			command.SetOut(buf)
+			command.SetErr(buf)
+
+			// The server shuts down right away, as the context is already done
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err = command.ExecuteContext(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			checkWantOut(t, tt, buf)
+		})
+
+		downloadServer.Close()
+	}
+}
@@ -9,7 +9,7 @@ import (
 func newSyncCmd(localAPIFS afero.Fs) *cobra.Command {
 	discoverCmd := &cobra.Command{
 		Use:   "sync [flags]",
-		Short: "Sync the list of supported tools",
+		Short: "Sync the list of supported tools and the index",
 		Args:  cobra.NoArgs,
 		RunE:  newRunSync(localAPIFS),
 	}
@@ -21,8 +21,9 @@ func newRunSync(
 	localAPIFS afero.Fs,
 ) func(cmd *cobra.Command, args []string) (err error) {
 	return func(cmd *cobra.Command, _ []string) (err error) {
-		// Needs to run with the local API because we need write access
-		toolctlAPI, err := api.New(localAPIFS, cmd, api.Local)
+		// The tools are detected from the directories, which can only be listed
+		// in a local registry
+		toolctlAPI, err := newWritableAPI(localAPIFS, cmd, true)
 		if err != nil {
 			return
 		}
@@ -41,6 +42,16 @@ func newRunSync(
 
 		// Save the metadata
 		err = api.SaveMeta(toolctlAPI, api.Meta{Tools: tools})
+		if err != nil {
+			return
+		}
+
+		// Save the index, which holds all metadata in a single file
+		index, err := api.BuildIndex(toolctlAPI)
+		if err != nil {
+			return
+		}
+		err = api.SaveIndex(toolctlAPI, index)
 		return
 	}
 }
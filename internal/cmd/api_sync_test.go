@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/cmd"
+	"github.com/toolctl/toolctl/internal/oci/ocitest"
+	"github.com/toolctl/toolctl/internal/s3/s3test"
 )
 
 func TestAPISyncCmd(t *testing.T) {
@@ -37,6 +39,9 @@ func TestAPISyncCmd(t *testing.T) {
   - toolctl-test-tool
 `,
 				},
+				{
+					Path: "index.json",
+				},
 			},
 		},
 	}
@@ -96,3 +101,56 @@ func TestAPISyncCmd(t *testing.T) {
 		downloadServer.Close()
 	}
 }
+
+func TestAPISyncCmdRemoteRegistry(t *testing.T) {
+	s3Server := s3test.NewServer("")
+	defer s3Server.Close()
+	ociRegistry := ocitest.NewRegistry()
+	defer ociRegistry.Close()
+
+	tests := []struct {
+		name     string
+		registry string
+		written  func() bool
+	}{
+		{
+			name:     "S3",
+			registry: "s3://toolctl/api/v0?endpoint=" + s3Server.URL(),
+			written: func() bool {
+				_, found := s3Server.Object("toolctl", "api/v0/meta.yaml")
+				return found
+			},
+		},
+		{
+			name:     "OCI",
+			registry: ociRegistry.Reference("toolctl/api") + ":v0",
+			written: func() bool {
+				_, found := ociRegistry.Manifest("toolctl/api", "v0")
+				return found
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			command := cmd.NewRootCmd(buf, afero.NewMemMapFs())
+			command.SetArgs([]string{"api", "sync", "--registry", tt.registry})
+
+			// Redirect Cobra output
+			command.SetOut(buf)
+			command.SetErr(buf)
+
+			err := command.Execute()
+			if err == nil {
+				t.Fatal("Error = nil, want an error")
+			}
+
+			checkWantOut(t, test{wantOut: "Error: api sync requires a local registry\n"}, buf)
+			if tt.written() {
+				t.Error("api sync wrote to the registry")
+			}
+		})
+	}
+}
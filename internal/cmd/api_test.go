@@ -19,13 +19,17 @@ Usage:
 
 Available Commands:
   discover    Discover new versions of supported tools
-  sync        Sync the list of supported tools
+  serve       Serve the local API over HTTP
+  sync        Sync the list of supported tools and the index
+  yank        Mark versions of tools as yanked
 
 Flags:
   -h, --help   help for api
 
 Global Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
 
 Use "toolctl api [command] --help" for more information about a command.
 `,
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+func newYankCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
+	yankCmd := &cobra.Command{
+		Use:   "yank TOOL@VERSION... --reason REASON [flags]",
+		Short: "Mark versions of tools as yanked",
+		Example: `  # Yank a version that was pulled upstream
+  toolctl api yank kubectl@1.20.13 --reason "release was pulled upstream"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: newRunYank(toolctlWriter, localAPIFS),
+	}
+
+	yankCmd.Flags().StringSlice("arch", []string{"amd64", "arm64"}, "comma-separated list of architectures")
+	yankCmd.Flags().StringSlice("os", []string{"darwin", "linux"}, "comma-separated list of operating systems")
+	yankCmd.Flags().String("reason", "", "why the versions were yanked")
+	_ = yankCmd.MarkFlagRequired("reason")
+
+	return yankCmd
+}
+
+func newRunYank(toolctlWriter io.Writer, localAPIFS afero.Fs) func(cmd *cobra.Command, args []string) (err error) {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		// The latest version is recalculated from the version files, which can
+		// only be listed in a local registry
+		toolctlAPI, err := newWritableAPI(localAPIFS, cmd, true)
+		if err != nil {
+			return err
+		}
+
+		// Get the command line flags
+		osArg, err := cmd.Flags().GetStringSlice("os")
+		if err != nil {
+			return err
+		}
+		archArg, err := cmd.Flags().GetStringSlice("arch")
+		if err != nil {
+			return err
+		}
+		reason, err := cmd.Flags().GetString("reason")
+		if err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			var tool api.Tool
+			tool, err = ArgToTool(arg, "", "", true)
+			if err != nil {
+				return
+			}
+			if tool.Version == "" {
+				return fmt.Errorf(
+					"no version specified, try this instead:\n  toolctl api yank %s@VERSION --reason REASON",
+					tool.Name,
+				)
+			}
+
+			var yanked int
+			for _, os := range osArg {
+				for _, arch := range archArg {
+					tool.OS, tool.Arch = os, arch
+
+					var found bool
+					found, err = yank(toolctlWriter, toolctlAPI, tool, reason)
+					if err != nil {
+						return
+					}
+					if found {
+						yanked++
+					}
+				}
+			}
+
+			if yanked == 0 {
+				return fmt.Errorf("%s v%s %w", tool.Name, tool.Version, api.NotFoundError{})
+			}
+		}
+
+		return
+	}
+}
+
+// yank marks a version of a tool as yanked on a platform, and makes sure it
+// isn't the latest version anymore.
+func yank(
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, tool api.Tool, reason string,
+) (found bool, err error) {
+	toolPlatformVersionMeta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+	if err != nil {
+		if errors.Is(err, api.NotFoundError{}) {
+			err = nil
+		}
+		return
+	}
+	found = true
+
+	toolPlatformVersionMeta.Yanked = true
+	toolPlatformVersionMeta.YankedReason = reason
+	err = api.SaveToolPlatformVersionMeta(toolctlAPI, tool, toolPlatformVersionMeta)
+	if err != nil {
+		return
+	}
+
+	toolMeta, err := api.GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+	err = rebuildToolPlatformMeta(toolctlAPI, toolMeta, tool)
+	if err != nil {
+		return
+	}
+	err = api.UpdateIndex(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(toolctlWriter, "%s %s/%s v%s yanked\n",
+		tool.Name, tool.OS, tool.Arch, tool.Version,
+	)
+	return
+}
+
+// rebuildToolPlatformMeta recalculates the tool platform metadata from all
+// versions of a tool in the local API.
+func rebuildToolPlatformMeta(
+	toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta, tool api.Tool,
+) (err error) {
+	scheme, err := api.GetVersionScheme(toolMeta)
+	if err != nil {
+		return
+	}
+
+	files, err := afero.ReadDir(
+		toolctlAPI.LocalAPIFS(),
+		filepath.Join(toolctlAPI.LocalAPIBasePath(), tool.Name, tool.OS+"-"+tool.Arch),
+	)
+	if err != nil {
+		return
+	}
+
+	var toolPlatformMeta api.ToolPlatformMeta
+	for _, file := range files {
+		versionString, isVersion := strings.CutSuffix(file.Name(), ".yaml")
+		if file.IsDir() || !isVersion || versionString == "meta" {
+			continue
+		}
+
+		var version *api.Version
+		version, err = scheme.Parse(versionString)
+		if err != nil {
+			return
+		}
+
+		tool.Version = versionString
+		var toolPlatformVersionMeta api.ToolPlatformVersionMeta
+		toolPlatformVersionMeta, err = api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+		if err != nil {
+			return
+		}
+
+		addVersionToToolPlatformMeta(
+			&toolPlatformMeta, scheme, version, toolPlatformVersionMeta.Yanked,
+		)
+	}
+
+	err = api.SaveToolPlatformMeta(toolctlAPI, tool, toolPlatformMeta)
+	return
+}
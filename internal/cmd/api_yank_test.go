@@ -0,0 +1,190 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/cmd"
+)
+
+func TestAPIYankCmd(t *testing.T) {
+	platformDir := filepath.Join("toolctl-test-tool", runtime.GOOS+"-"+runtime.GOARCH)
+
+	tests := []test{
+		{
+			name: "latest version",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@0.1.1", "--reason", "broken release"},
+			wantOutRegex: `^toolctl-test-tool (darwin|linux)/(amd|arm)64 v0.1.1 yanked
+$`,
+			wantFiles: []APIFile{
+				{
+					Path: filepath.Join(platformDir, "0.1.1.yaml"),
+					Contents: `(?m)^yanked: true
+yankedReason: broken release$`,
+				},
+				{
+					Path: filepath.Join(platformDir, "meta.yaml"),
+					Contents: `^version:
+  earliest: 0.1.0
+  latest: 0.1.0
+$`,
+				},
+			},
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "unknown version",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@0.2.0", "--reason", "broken release"},
+			wantErr: true,
+			wantOut: `Error: toolctl-test-tool v0.2.0 could not be found
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "no version",
+			cliArgs: []string{"toolctl-test-tool", "--reason", "broken release"},
+			wantErr: true,
+			wantOut: `Error: no version specified, try this instead:
+  toolctl api yank toolctl-test-tool@VERSION --reason REASON
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:         "no reason",
+			cliArgs:      []string{"toolctl-test-tool@0.1.0"},
+			wantErr:      true,
+			wantOutRegex: `^Error: required flag\(s\) "reason" not set\n`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "remote registry",
+			cliArgs: []string{
+				"toolctl-test-tool@0.1.0", "--reason", "broken release",
+				"--registry", "https://example.com/toolctl/api/v0",
+			},
+			wantErr: true,
+			wantOut: `Error: api yank requires a local registry
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		localAPIFS, downloadServer, err := setupLocalAPI(tt.supportedTools, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			command := cmd.NewRootCmd(buf, localAPIFS)
+			command.SetArgs(append([]string{"api", "yank"}, tt.cliArgs...))
+			viper.Set("LocalAPIBasePath", localAPIBasePath)
+
+			// Redirect Cobra output
+			command.SetOut(buf)
+			command.SetErr(buf)
+
+			err = command.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			checkWantOut(t, tt, buf)
+
+			for _, file := range tt.wantFiles {
+				contents, err := afero.ReadFile(localAPIFS, filepath.Join(localAPIBasePath, file.Path))
+				if err != nil {
+					t.Errorf("Error checking file %s: %v", file.Path, err)
+					continue
+				}
+				if !regexp.MustCompile(file.Contents).Match(contents) {
+					t.Errorf("File %s does not match %s:\n%s", file.Path, file.Contents, contents)
+				}
+			}
+		})
+
+		downloadServer.Close()
+	}
+}
+
+func TestAPIYankCmdAfterSync(t *testing.T) {
+	localAPIFS, downloadServer, err := setupLocalAPI([]supportedTool{
+		{
+			name:    "toolctl-test-tool",
+			version: "0.1.0",
+			tarGz:   true,
+		},
+		{
+			name:    "toolctl-test-tool",
+			version: "0.1.1",
+			tarGz:   true,
+		},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downloadServer.Close()
+
+	for _, args := range [][]string{
+		{"api", "sync"},
+		{"api", "yank", "toolctl-test-tool@0.1.1", "--reason", "broken release"},
+	} {
+		buf := new(bytes.Buffer)
+		command := cmd.NewRootCmd(buf, localAPIFS)
+		command.SetArgs(args)
+		viper.Set("LocalAPIBasePath", localAPIBasePath)
+		command.SetOut(buf)
+		command.SetErr(buf)
+
+		err = command.Execute()
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, buf)
+		}
+	}
+
+	// The index has to reflect the yank, as clients prefer it over the files
+	contents, err := afero.ReadFile(localAPIFS, filepath.Join(localAPIBasePath, api.IndexPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index api.Index
+	err = json.Unmarshal(contents, &index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform := index.Tools["toolctl-test-tool"].Platforms[runtime.GOOS+"-"+runtime.GOARCH]
+	if !platform.Versions["0.1.1"].Yanked {
+		t.Errorf("index: 0.1.1 is not yanked")
+	}
+	if platform.Meta.Version.Latest != "0.1.0" {
+		t.Errorf("index: latest = %s, want 0.1.0", platform.Meta.Version.Latest)
+	}
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/toolctl/toolctl/internal/advisory"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+var advisoryDatabaseFlag string
+
+func newAuditCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
+	var auditCmd = &cobra.Command{
+		Use:   "audit [TOOL...]",
+		Short: "Check installed tools for known vulnerabilities",
+		Args:  checkArgs(true),
+		Example: `  # Check all installed tools against the configured advisory database
+  toolctl audit
+
+  # Check a specific tool against a mirrored OSV advisory directory
+  toolctl audit kubectl --advisory-database ~/osv`,
+		RunE: newRunAudit(toolctlWriter, localAPIFS),
+	}
+
+	// Flags
+	auditCmd.Flags().StringVar(
+		&advisoryDatabaseFlag, "advisory-database", "",
+		"directory, ZIP file or URL of the OSV advisory database",
+	)
+
+	return auditCmd
+}
+
+func newRunAudit(
+	toolctlWriter io.Writer, localAPIFS afero.Fs,
+) func(*cobra.Command, []string) (err error) {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		location := advisoryDatabaseFlag
+		if location == "" {
+			location = viper.GetString("AdvisoryDatabase")
+		}
+		if location == "" {
+			return fmt.Errorf(
+				"no advisory database configured, set AdvisoryDatabase in the config file or use --advisory-database",
+			)
+		}
+		db, err := advisory.Load(location)
+		if err != nil {
+			return
+		}
+
+		toolctlAPI, err := api.New(localAPIFS, cmd)
+		if err != nil {
+			return err
+		}
+
+		// If no args were specified, audit all installed tools
+		if len(args) == 0 {
+			var meta api.Meta
+			meta, err = api.GetMeta(toolctlAPI)
+			if err != nil {
+				return
+			}
+			for _, toolName := range meta.Tools {
+				var installed bool
+				installed, err = isToolInstalled(toolName)
+				if err != nil {
+					return
+				}
+				if installed {
+					args = append(args, toolName)
+				}
+			}
+
+			if len(args) == 0 {
+				err = fmt.Errorf("no supported tools installed")
+				return
+			}
+		}
+
+		allTools, err := ArgsToTools(args, runtime.GOOS, runtime.GOARCH, false)
+		if err != nil {
+			return fmt.Errorf(
+				"%w, try this instead:\n  toolctl audit %s",
+				err, strings.Join(stripVersionsFromArgs(args), " "),
+			)
+		}
+
+		var vulnerable int
+		for _, tool := range allTools {
+			var isVulnerable bool
+			isVulnerable, err = audit(toolctlWriter, toolctlAPI, db, tool, allTools)
+			if err != nil {
+				return
+			}
+			if isVulnerable {
+				vulnerable++
+			}
+		}
+
+		if vulnerable > 0 {
+			err = fmt.Errorf("audit failed: %d of %d tools are vulnerable", vulnerable, len(allTools))
+		}
+
+		return
+	}
+}
+
+// audit checks the installed version of a tool against the advisory
+// database and suggests an upgrade if a fixed version is available.
+func audit(
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, db advisory.Database,
+	tool api.Tool, allTools []api.Tool,
+) (isVulnerable bool, err error) {
+	installedVersion, err := installedToolVersion(toolctlAPI, tool)
+	if err != nil {
+		if isVersionUndetermined(err) {
+			fmt.Fprintln(
+				toolctlWriter,
+				prependToolName(tool, allTools, "🤷 Unknown: the installed version could not be determined"),
+			)
+			err = nil
+		}
+		return
+	}
+
+	if _, semverErr := installedVersion.Semver(); semverErr != nil {
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"🤷 Unknown: advisories can't be matched against v%s", installedVersion,
+			)),
+		)
+		return
+	}
+
+	matches := findAdvisories(db, tool, installedVersion)
+	if len(matches) == 0 {
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"✅ No known vulnerabilities in v%s", installedVersion,
+			)),
+		)
+		return
+	}
+	isVulnerable = true
+
+	printAdvisories(toolctlWriter, tool, allTools, installedVersion, matches)
+
+	fixedVersion := requiredFixedVersion(matches)
+	if fixedVersion == nil {
+		return
+	}
+	latestVersion, err := getLatestVersion(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+	latestSemver, err := latestVersion.Semver()
+	if err != nil {
+		return
+	}
+	if latestSemver.LessThan(fixedVersion) {
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"⏳ v%s is not available yet, the latest version is v%s",
+				fixedVersion, latestVersion,
+			)),
+		)
+		return
+	}
+	fmt.Fprintln(
+		toolctlWriter,
+		prependToolName(tool, allTools, fmt.Sprintf(
+			"💡 Upgrade to v%s or later:\n  toolctl upgrade %s",
+			latestVersion, tool.Name,
+		)),
+	)
+
+	return
+}
+
+// installedToolVersion returns the installed version of a tool, preferring
+// the version recorded in the receipt over running the tool binary.
+func installedToolVersion(
+	toolctlAPI api.ToolctlAPI, tool api.Tool,
+) (version *api.Version, err error) {
+	toolMeta, err := api.GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	found, r, err := loadReceipt(tool.Name)
+	if err != nil {
+		return
+	}
+	if found {
+		return api.ParseVersion(toolMeta, r.Version)
+	}
+
+	installedToolPath, err := which(tool.Name)
+	if err != nil {
+		return
+	}
+	if installedToolPath == "" {
+		err = fmt.Errorf("%s is not installed", tool.Name)
+		return
+	}
+
+	return getToolBinaryVersion(installedToolPath, toolMeta)
+}
+
+// findAdvisories returns the advisories that apply to a version of a tool.
+// Advisories use semantic versions, so there are none for versions that can't
+// be converted.
+func findAdvisories(
+	db advisory.Database, tool api.Tool, version *api.Version,
+) (matches []advisory.Match) {
+	semverVersion, err := version.Semver()
+	if err != nil {
+		return
+	}
+	return db.Find(tool.Name, semverVersion)
+}
+
+// printAdvisories prints the advisories that apply to a version of a tool.
+func printAdvisories(
+	toolctlWriter io.Writer, tool api.Tool, allTools []api.Tool,
+	version *api.Version, matches []advisory.Match,
+) {
+	for _, match := range matches {
+		id := match.Advisory.ID
+		if match.Advisory.Summary != "" {
+			id += " (" + match.Advisory.Summary + ")"
+		}
+		fixed := "no fixed version available"
+		if match.Fixed != nil {
+			fixed = fmt.Sprintf("fixed in v%s", match.Fixed)
+		}
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"🚨 Vulnerable: v%s is affected by %s, %s", version, id, fixed,
+			)),
+		)
+	}
+}
+
+// requiredFixedVersion returns the lowest version that fixes all the given
+// advisories, or nil if any of them has no fixed version.
+func requiredFixedVersion(matches []advisory.Match) (fixedVersion *semver.Version) {
+	if slices.ContainsFunc(matches, func(match advisory.Match) bool {
+		return match.Fixed == nil
+	}) {
+		return nil
+	}
+	for _, match := range matches {
+		if fixedVersion == nil || match.Fixed.GreaterThan(fixedVersion) {
+			fixedVersion = match.Fixed
+		}
+	}
+	return
+}
+
+// loadConfiguredAdvisoryDatabase loads the advisory database from the
+// config, if one is configured.
+func loadConfiguredAdvisoryDatabase() (db *advisory.Database, err error) {
+	location := viper.GetString("AdvisoryDatabase")
+	if location == "" {
+		return
+	}
+	loadedDB, err := advisory.Load(location)
+	if err != nil {
+		return
+	}
+	db = &loadedDB
+	return
+}
@@ -0,0 +1,110 @@
+package cmd_test
+
+import (
+	"testing"
+)
+
+func TestAuditCmd(t *testing.T) {
+	advisoryConfig := map[string]any{
+		"AdvisoryDatabase": "testdata/advisories",
+	}
+
+	tests := []test{
+		{
+			name:    "no advisory database configured",
+			cliArgs: []string{},
+			wantErr: true,
+			wantOut: `Error: no advisory database configured, set AdvisoryDatabase in the config file or use --advisory-database
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "vulnerable tool, fixed version available",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+				},
+			},
+			config:  advisoryConfig,
+			cliArgs: []string{},
+			wantErr: true,
+			wantOut: `🚨 Vulnerable: v0.1.0 is affected by OSV-2024-0001 (Remote code execution), fixed in v0.1.1
+💡 Upgrade to v0.1.1 or later:
+  toolctl upgrade toolctl-test-tool
+Error: audit failed: 1 of 1 tools are vulnerable
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "multiple tools, fixed version not available yet",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+				{
+					name:    "toolctl-other-test-tool",
+					version: "0.2.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.1"
+`,
+				},
+				{
+					name: "toolctl-other-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.2.1"
+`,
+				},
+			},
+			config:  advisoryConfig,
+			cliArgs: []string{"toolctl-test-tool", "toolctl-other-test-tool"},
+			wantErr: true,
+			wantOut: `[toolctl-test-tool      ] ✅ No known vulnerabilities in v0.1.1
+[toolctl-other-test-tool] 🚨 Vulnerable: v0.2.1 is affected by OSV-2024-0002 (Denial of service), fixed in v0.3.0
+[toolctl-other-test-tool] ⏳ v0.3.0 is not available yet, the latest version is v0.2.1
+Error: audit failed: 1 of 2 tools are vulnerable
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "advisory database flag",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.1"
+`,
+				},
+			},
+			cliArgs: []string{"--advisory-database", "testdata/advisories"},
+			wantOut: `✅ No known vulnerabilities in v0.1.1
+`,
+		},
+	}
+
+	runInstallUpgradeTests(t, tests, "audit")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"errors"
+	"fmt"
+
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+// elfMachines maps Go architectures to the corresponding ELF machine types.
+var elfMachines = map[string]elf.Machine{
+	"386":     elf.EM_386,
+	"amd64":   elf.EM_X86_64,
+	"arm":     elf.EM_ARM,
+	"arm64":   elf.EM_AARCH64,
+	"ppc64le": elf.EM_PPC64,
+	"riscv64": elf.EM_RISCV,
+	"s390x":   elf.EM_S390,
+}
+
+// machoCPUs maps Go architectures to the corresponding Mach-O CPU types.
+var machoCPUs = map[string]macho.Cpu{
+	"386":   macho.Cpu386,
+	"amd64": macho.CpuAmd64,
+	"arm":   macho.CpuArm,
+	"arm64": macho.CpuArm64,
+}
+
+// checkBinaryPlatform verifies that the file at binaryPath is an executable
+// for the OS and architecture of the given tool.
+func checkBinaryPlatform(tool api.Tool, binaryPath string) (err error) {
+	switch tool.OS {
+	case "darwin":
+		err = checkMachOBinary(tool, binaryPath)
+	case "linux":
+		err = checkELFBinary(tool, binaryPath)
+	default:
+		err = fmt.Errorf("unsupported operating system: %s", tool.OS)
+	}
+	if err != nil {
+		err = fmt.Errorf(
+			"%s is not an executable for %s/%s: %w",
+			tool.Name, tool.OS, tool.Arch, err,
+		)
+	}
+	return
+}
+
+// checkELFBinary verifies that the file at binaryPath is an ELF executable
+// for the architecture of the given tool.
+func checkELFBinary(tool api.Tool, binaryPath string) (err error) {
+	wantMachine, ok := elfMachines[tool.Arch]
+	if !ok {
+		err = fmt.Errorf("unsupported architecture")
+		return
+	}
+
+	elfFile, err := elf.Open(binaryPath)
+	if err != nil {
+		var formatError *elf.FormatError
+		if errors.As(err, &formatError) {
+			err = fmt.Errorf("not an ELF file")
+		}
+		return
+	}
+	defer elfFile.Close()
+
+	if elfFile.Type != elf.ET_EXEC && elfFile.Type != elf.ET_DYN {
+		err = fmt.Errorf("unexpected ELF type %s", elfFile.Type)
+		return
+	}
+	if elfFile.Machine != wantMachine {
+		err = fmt.Errorf("unexpected ELF machine %s", elfFile.Machine)
+		return
+	}
+
+	return
+}
+
+// checkMachOBinary verifies that the file at binaryPath is a Mach-O executable
+// for the architecture of the given tool. Universal binaries are accepted if
+// they contain the architecture.
+func checkMachOBinary(tool api.Tool, binaryPath string) (err error) {
+	wantCPU, ok := machoCPUs[tool.Arch]
+	if !ok {
+		err = fmt.Errorf("unsupported architecture")
+		return
+	}
+
+	fatFile, err := macho.OpenFat(binaryPath)
+	if err == nil {
+		defer fatFile.Close()
+		for _, arch := range fatFile.Arches {
+			if arch.Cpu == wantCPU && arch.Type == macho.TypeExec {
+				return
+			}
+		}
+		err = fmt.Errorf("universal binary does not contain %s", tool.Arch)
+		return
+	}
+
+	machoFile, err := macho.Open(binaryPath)
+	if err != nil {
+		var formatError *macho.FormatError
+		if errors.As(err, &formatError) {
+			err = fmt.Errorf("not a Mach-O file")
+		}
+		return
+	}
+	defer machoFile.Close()
+
+	if machoFile.Type != macho.TypeExec {
+		err = fmt.Errorf("unexpected Mach-O type %s", machoFile.Type)
+		return
+	}
+	if machoFile.Cpu != wantCPU {
+		err = fmt.Errorf("unexpected Mach-O CPU %s", machoFile.Cpu)
+		return
+	}
+
+	return
+}
@@ -2,15 +2,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/toolctl/toolctl/internal/httpclient"
 )
 
 // toolctlWriter is a writer that prints to stdout. When testing, we replace
@@ -22,9 +27,18 @@ func (t toolctlWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-// Execute uses the default settings and executes the root command.
+// Execute uses the default settings and executes the root command. Ctrl-C
+// cancels the context of the command, which aborts in-flight HTTP requests.
+// Only the first Ctrl-C is captured, so a second one kills toolctl, e.g. while
+// it waits for a tool binary that doesn't exit.
 func Execute() {
-	err := NewRootCmd(toolctlWriter{}, afero.NewOsFs()).Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := NewRootCmd(toolctlWriter{}, afero.NewOsFs()).ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		// Cobra prints the error message
 		os.Exit(1)
@@ -42,6 +56,13 @@ func initConfig() {
 
 	viper.SetDefault("RemoteAPIBaseURL", "https://raw.githubusercontent.com/toolctl/api/main/v0/")
 	viper.SetDefault("InstallDir", filepath.Join(home, ".local", "bin"))
+	viper.SetDefault("ReceiptsDir", filepath.Join(home, ".local", "share", "toolctl", "receipts"))
+	viper.SetDefault("HTTPConnectTimeout", "10s")
+	viper.SetDefault("HTTPReadTimeout", "30s")
+	viper.SetDefault("APICacheTTL", "1h")
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		viper.SetDefault("CacheDir", filepath.Join(cacheDir, "toolctl"))
+	}
 
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
@@ -57,3 +78,49 @@ func initConfig() {
 		}
 	}
 }
+
+// configureHTTPClient sets up the shared HTTP client according to the config,
+// bound to the context of the command that is being executed.
+func configureHTTPClient(ctx context.Context) (err error) {
+	config := httpclient.Config{
+		Proxy:     viper.GetString("HTTPProxy"),
+		CAFile:    viper.GetString("HTTPCAFile"),
+		UserAgent: "toolctl/" + gitVersion,
+	}
+
+	for _, timeout := range []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"HTTPConnectTimeout", &config.ConnectTimeout},
+		{"HTTPReadTimeout", &config.ReadTimeout},
+	} {
+		value := viper.GetString(timeout.key)
+		if value == "" {
+			continue
+		}
+		*timeout.value, err = time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", timeout.key, err)
+		}
+	}
+
+	err = viper.UnmarshalKey("Auth", &config.Auth)
+	if err != nil {
+		return fmt.Errorf("invalid Auth: %w", err)
+	}
+	if os.Getenv("GITHUB_TOKEN") != "" && !slices.ContainsFunc(
+		config.Auth, func(auth httpclient.HostAuth) bool { return auth.Host == githubAPIHost },
+	) {
+		config.Auth = append(config.Auth, httpclient.HostAuth{
+			Host: githubAPIHost, TokenEnv: "GITHUB_TOKEN",
+		})
+	}
+
+	client, err := httpclient.New(ctx, config)
+	if err != nil {
+		return
+	}
+	httpclient.SetDefault(client)
+	return
+}
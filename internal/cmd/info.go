@@ -6,12 +6,11 @@ import (
 	"io"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/toolctl/toolctl/internal/api"
@@ -39,7 +38,7 @@ func newRunInfo(
 	toolctlWriter io.Writer, localAPIFS afero.Fs,
 ) func(*cobra.Command, []string) (err error) {
 	return func(cmd *cobra.Command, args []string) (err error) {
-		toolctlAPI, err := api.New(localAPIFS, cmd, api.Remote)
+		toolctlAPI, err := api.New(localAPIFS, cmd)
 		if err != nil {
 			return err
 		}
@@ -103,8 +102,8 @@ func info(
 		return
 	}
 
-	var latestVersion *semver.Version
-	latestVersion, err = api.GetLatestVersion(toolctlAPI, tool)
+	var latestVersion *api.Version
+	latestVersion, err = getLatestVersion(toolctlAPI, tool)
 	if err != nil {
 		if errors.Is(err, api.NotFoundError{}) {
 			err = fmt.Errorf(
@@ -121,6 +120,20 @@ func info(
 			tool.Name, latestVersion.String(), toolMeta.Description),
 		),
 	)
+	registry, err := api.GetToolRegistry(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+	if registry != "" {
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf("📚 From the %s registry", registry)),
+		)
+	}
+	err = printReleaseAge(toolctlWriter, toolctlAPI, tool, allTools, latestVersion)
+	if err != nil {
+		return
+	}
 
 	// Check if the tool is already installed
 	installedToolPath, err := which(tool.Name)
@@ -142,7 +155,8 @@ func info(
 	}
 
 	err = installPrintInstalledVersion(
-		installedToolPath, toolMeta, toolctlWriter, tool, allTools, latestVersion,
+		installedToolPath, toolctlAPI, toolMeta, toolctlWriter, tool, allTools,
+		latestVersion,
 	)
 	if err != nil {
 		return
@@ -172,16 +186,16 @@ func info(
 }
 
 func installPrintInstalledVersion(
-	installedToolPath string, toolMeta api.ToolMeta, toolctlWriter io.Writer,
-	tool api.Tool, allTools []api.Tool, latestVersion *semver.Version,
+	installedToolPath string, toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta,
+	toolctlWriter io.Writer, tool api.Tool, allTools []api.Tool,
+	latestVersion *api.Version,
 ) (err error) {
-	var installedVersion *semver.Version
+	var installedVersion *api.Version
 	installedVersion, err = getToolBinaryVersion(
-		installedToolPath, toolMeta.VersionArgs,
+		installedToolPath, toolMeta,
 	)
 	if err != nil {
-		var exitError *exec.ExitError
-		if !errors.As(err, &exitError) {
+		if !isVersionUndetermined(err) {
 			return
 		}
 
@@ -211,7 +225,54 @@ func installPrintInstalledVersion(
 				wrapInQuotesIfContainsSpace(installedToolPath)),
 			),
 		)
+		err = printReleaseAge(toolctlWriter, toolctlAPI, tool, allTools, installedVersion)
+		if errors.Is(err, api.NotFoundError{}) {
+			// The installed version may not be in the API
+			err = nil
+		}
+	}
+	if err != nil {
+		return
 	}
 
+	// Warn if the installed version has been pulled
+	tool.Version = installedVersion.String()
+	toolPlatformVersionMeta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+	if err != nil {
+		if errors.Is(err, api.NotFoundError{}) {
+			err = nil
+		}
+		return
+	}
+	if toolPlatformVersionMeta.Yanked {
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"⚠️ v%s has been yanked: %s", installedVersion, toolPlatformVersionMeta.YankedReason,
+			)),
+		)
+	}
+
+	return
+}
+
+// printReleaseAge prints how long ago a version of a tool was released, if
+// the release date is known.
+func printReleaseAge(
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, tool api.Tool,
+	allTools []api.Tool, version *api.Version,
+) (err error) {
+	releaseDate, err := getReleaseDate(toolctlAPI, tool, version)
+	if err != nil || releaseDate.IsZero() {
+		return
+	}
+
+	fmt.Fprintln(
+		toolctlWriter,
+		prependToolName(tool, allTools, fmt.Sprintf(
+			"📅 v%s was released %s ago (%s)",
+			version, formatAge(time.Since(releaseDate)), releaseDate.Format(time.DateOnly),
+		)),
+	)
 	return
 }
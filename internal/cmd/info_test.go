@@ -35,7 +35,9 @@ Flags:
   -h, --help   help for info
 
 Global Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
 `,
 		},
 		// -------------------------------------------------------------------------
@@ -67,6 +69,68 @@ echo "v0.1.0"
 			},
 			wantOutRegex: `^✨ toolctl-test-tool v0.1.1: toolctl test tool
 🔄 toolctl-test-tool v0.1.0 is installed at .+
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool, installed version is yanked",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.0",
+					tarGz:        true,
+					yankedReason: "broken release",
+				},
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+				},
+			},
+			wantOutRegex: `^✨ toolctl-test-tool v0.1.1: toolctl test tool
+🔄 toolctl-test-tool v0.1.0 is installed at .+
+⚠️ v0.1.0 has been yanked: broken release
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with release dates",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.0",
+					tarGz:       true,
+					releaseDate: "2024-01-10T12:00:00Z",
+				},
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: "2024-03-15T12:00:00Z",
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+				},
+			},
+			wantOutRegex: `^✨ toolctl-test-tool v0.1.1: toolctl test tool
+📅 v0.1.1 was released \d+ days ago \(2024-03-15\)
+🔄 toolctl-test-tool v0.1.0 is installed at .+
+📅 v0.1.0 was released \d+ days ago \(2024-01-10\)
 $`,
 		},
 		// -------------------------------------------------------------------------
@@ -196,6 +260,154 @@ exit 1
 			wantErr: false,
 			wantOut: `✨ toolctl-test-tool v0.1.1: toolctl test tool
 ❌ Could not determine installed version: version flag not supported (exit status 1)
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, prerelease version installed",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.1",
+					tarGz:        true,
+					versionRegex: `v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)`,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.1-rc.1+3f2a1b"
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOutRegex: `✨ toolctl-test-tool v0.1.1: toolctl test tool
+🔄 toolctl-test-tool v0.1.1-rc.1\+3f2a1b is installed at .+
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, version with platform suffix installed",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.1-linux-amd64"
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOutRegex: `✨ toolctl-test-tool v0.1.1: toolctl test tool
+✅ toolctl-test-tool v0.1.1 is installed at .+
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, version printed to stderr",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "0.1.1",
+					tarGz:         true,
+					versionStream: "stderr",
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "built with go1.22.1"
+echo "v0.1.1" >&2
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOutRegex: `✨ toolctl-test-tool v0.1.1: toolctl test tool
+✅ toolctl-test-tool v0.1.1 is installed at .+
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, version regex",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "0.1.1",
+					tarGz:         true,
+					versionRegex:  `toolctl-test-tool (?P<version>\d+\.\d+)`,
+					versionStream: "both",
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "go1.22.1" >&2
+echo "toolctl-test-tool 0.1"
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOutRegex: `✨ toolctl-test-tool v0.1.1: toolctl test tool
+🔄 toolctl-test-tool v0.1.0 is installed at .+
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, version could not be determined from both streams",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "0.1.1",
+					tarGz:         true,
+					versionStream: "both",
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "version flag not supported" >&2
+exit 1
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `✨ toolctl-test-tool v0.1.1: toolctl test tool
+❌ Could not determine installed version: version flag not supported (exit status 1)
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, invalid version stream",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "0.1.1",
+					tarGz:         true,
+					versionStream: "stdin",
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.1"
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOut: `✨ toolctl-test-tool v0.1.1: toolctl test tool
+Error: invalid versionStream stdin, must be stdout, stderr or both
 `,
 		},
 		// -------------------------------------------------------------------------
@@ -293,3 +505,55 @@ $`,
 		downloadServer.Close()
 	}
 }
+
+func TestInfoCmdRegistries(t *testing.T) {
+	companyAPI, companyAPIServer, companyDownloadServer, err := setupRemoteAPI(
+		[]supportedTool{{name: "toolctl-test-tool", version: "0.2.0", tarGz: true}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer companyAPIServer.Close()
+	defer companyDownloadServer.Close()
+
+	_, publicAPIServer, publicDownloadServer, err := setupRemoteAPI([]supportedTool{
+		{name: "toolctl-test-tool", version: "0.1.1", tarGz: true},
+		{name: "toolctl-other-test-tool", version: "0.1.0", tarGz: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publicAPIServer.Close()
+	defer publicDownloadServer.Close()
+
+	tt := test{
+		wantOut: `[toolctl-test-tool      ] ✨ toolctl-test-tool v0.2.0: toolctl test tool
+[toolctl-test-tool      ] 📚 From the company registry
+[toolctl-test-tool      ] 🏠 https://toolctl.io/
+[toolctl-test-tool      ] ❌ Not installed
+[toolctl-other-test-tool] ✨ toolctl-other-test-tool v0.1.0: toolctl test tool
+[toolctl-other-test-tool] 📚 From the public registry
+[toolctl-other-test-tool] 🏠 https://toolctl.io/
+[toolctl-other-test-tool] ❌ Not installed
+`,
+	}
+
+	buf := new(bytes.Buffer)
+	command := cmd.NewRootCmd(buf, companyAPI.LocalAPIFS())
+	command.SetArgs([]string{"info", "toolctl-test-tool", "toolctl-other-test-tool"})
+	viper.Set("Registries", []map[string]any{
+		{"name": "company", "url": companyAPIServer.URL},
+		{"name": "public", "url": publicAPIServer.URL},
+	})
+	defer viper.Set("Registries", nil)
+
+	// Redirect Cobra output
+	command.SetOut(buf)
+	command.SetErr(buf)
+
+	err = command.Execute()
+	if err != nil {
+		t.Errorf("Error = %v", err)
+	}
+	checkWantOut(t, tt, buf)
+}
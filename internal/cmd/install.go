@@ -1,24 +1,26 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/api"
 	"github.com/toolctl/toolctl/internal/sysutil"
+	"github.com/toolctl/toolctl/internal/verify"
 	"golang.org/x/sys/unix"
 )
 
+var installForceFlag bool
+
 func newInstallCmd(
 	toolctlWriter io.Writer, localAPIFS afero.Fs,
 ) *cobra.Command {
@@ -31,11 +33,20 @@ func newInstallCmd(
   # Install a specified version of a tool
   toolctl install kubectl@1.20.13
 
+  # Install the latest version of a tool in a release channel
+  toolctl install kubectl@beta
+
   # Install multiple tools
   toolctl install gh k9s`,
 		Args: checkArgs(false),
 		RunE: newRunInstall(toolctlWriter, localAPIFS),
 	}
+
+	// Flags
+	installCmd.Flags().BoolVar(
+		&installForceFlag, "force", false, "install versions even if they have been yanked",
+	)
+
 	return installCmd
 }
 
@@ -43,7 +54,7 @@ func newRunInstall(
 	toolctlWriter io.Writer, localAPIFS afero.Fs,
 ) func(cmd *cobra.Command, args []string) (err error) {
 	return func(cmd *cobra.Command, args []string) (err error) {
-		toolctlAPI, err := api.New(localAPIFS, cmd, api.Remote)
+		toolctlAPI, err := api.New(localAPIFS, cmd)
 		if err != nil {
 			return err
 		}
@@ -135,12 +146,24 @@ func install(
 	}
 
 	// Check if a version has been specified
-	latestVersion, err := api.GetLatestVersion(toolctlAPI, tool)
+	latestVersion, err := getLatestVersion(toolctlAPI, tool)
 	if err != nil {
 		return
 	}
+	versionPinned := false
 	if tool.Version == "" {
 		tool.Version = latestVersion.String()
+	} else if _, versionErr := api.ParseVersion(toolMeta, tool.Version); versionErr != nil {
+		// Not a version, but a release channel, e.g. tool@beta
+		latestVersion, err = api.GetLatestVersionInChannel(
+			toolctlAPI, tool, strings.ToLower(tool.Version),
+		)
+		if err != nil {
+			return
+		}
+		tool.Version = latestVersion.String()
+	} else {
+		versionPinned = true
 	}
 
 	// Check if the tool is already installed
@@ -156,6 +179,28 @@ func install(
 		return
 	}
 
+	// Versions that weren't pinned may be too recent to be trusted yet, in
+	// which case the newest version that is old enough is installed instead.
+	// If there is none, nothing was installed, which is an error.
+	if !versionPinned {
+		var version *api.Version
+		var tooRecent string
+		version, tooRecent, err = getOldEnoughVersion(toolctlAPI, tool, latestVersion)
+		if err != nil {
+			return
+		}
+		if version == nil {
+			err = fmt.Errorf(
+				"%s %s, and no older version was released long enough ago", tool.Name, tooRecent,
+			)
+			return
+		}
+		if tooRecent != "" {
+			fmt.Fprintln(toolctlWriter, prependToolName(tool, allTools, "⏳ "+tooRecent))
+		}
+		tool.Version = version.String()
+	}
+
 	fmt.Fprintln(
 		toolctlWriter,
 		prependToolName(tool, allTools, fmt.Sprintf(
@@ -170,7 +215,9 @@ func install(
 	}
 	defer os.RemoveAll(tempDir)
 
-	downloadedToolPath, err := downloadTool(toolctlAPI, tool, tempDir)
+	downloadedToolPath, toolPlatformVersionMeta, err := downloadTool(
+		toolctlAPI, toolMeta, tool, tempDir,
+	)
 	if err != nil {
 		return
 	}
@@ -181,6 +228,12 @@ func install(
 		return
 	}
 
+	// Make sure the binary was built for this platform
+	err = checkBinaryPlatform(tool, extractedToolPath)
+	if err != nil {
+		return
+	}
+
 	// Install the tool
 	installPath := filepath.Join(installDir, tool.Name)
 	err = sysutil.MoveFile(extractedToolPath, installPath)
@@ -195,13 +248,17 @@ func install(
 	}
 
 	installedVersion, err := getToolBinaryVersion(
-		installPath, toolMeta.VersionArgs,
+		installPath, toolMeta,
 	)
 	if err != nil {
 		return
 	}
 
-	if !installedVersion.Equal(semver.MustParse(tool.Version)) {
+	version, err := api.ParseVersion(toolMeta, tool.Version)
+	if err != nil {
+		return
+	}
+	if !installedVersion.Equal(version) {
 		err = fmt.Errorf(
 			"installation failed: expected v%s, but installed binary reported v%s",
 			tool.Version, installedVersion.String(),
@@ -209,6 +266,24 @@ func install(
 		return
 	}
 
+	// Record the installation, so that it can be verified later on
+	binarySHA256, err := calculateFileSHA256(installPath)
+	if err != nil {
+		return
+	}
+	err = saveReceipt(receipt{
+		Name:         tool.Name,
+		Version:      tool.Version,
+		URL:          toolPlatformVersionMeta.URL,
+		SHA256:       toolPlatformVersionMeta.SHA256,
+		BinarySHA256: binarySHA256,
+		InstallPath:  installPath,
+		InstalledAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+
 	fmt.Fprintln(
 		toolctlWriter,
 		prependToolName(tool, allTools, "🎉 Successfully installed"),
@@ -219,15 +294,14 @@ func install(
 
 func infoPrintInstalledVersion(
 	installedToolPath string, toolMeta api.ToolMeta, toolctlWriter io.Writer,
-	tool api.Tool, allTools []api.Tool, latestVersion *semver.Version,
+	tool api.Tool, allTools []api.Tool, latestVersion *api.Version,
 ) (err error) {
-	var installedVersion *semver.Version
+	var installedVersion *api.Version
 	installedVersion, err = getToolBinaryVersion(
-		installedToolPath, toolMeta.VersionArgs,
+		installedToolPath, toolMeta,
 	)
 	if err != nil {
-		var exitError *exec.ExitError
-		if !errors.As(err, &exitError) {
+		if !isVersionUndetermined(err) {
 			return
 		}
 
@@ -271,26 +345,157 @@ func infoPrintInstalledVersion(
 // downloadTool gets the download URL for the specified tool and
 // downloads it to the specified directory.
 func downloadTool(
-	toolctlAPI api.ToolctlAPI, tool api.Tool, dir string,
-) (downloadedToolPath string, err error) {
-	meta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+	toolctlAPI api.ToolctlAPI, toolMeta api.ToolMeta, tool api.Tool, dir string,
+) (downloadedToolPath string, meta api.ToolPlatformVersionMeta, err error) {
+	meta, err = api.GetToolPlatformVersionMeta(toolctlAPI, tool)
 	if err != nil {
 		return
 	}
 
+	if meta.Yanked && !installForceFlag {
+		err = fmt.Errorf(
+			"%s v%s has been yanked (%s), use --force to install it anyway",
+			tool.Name, tool.Version, meta.YankedReason,
+		)
+		return
+	}
+
 	var sha256 string
-	downloadedToolPath, sha256, err = downloadURL(meta.URL, dir)
+	downloadedToolPath, sha256, err = downloadFromSources(meta, dir)
 	if err != nil {
 		return
 	}
 
-	if sha256 != meta.SHA256 {
-		err = fmt.Errorf(
-			"SHA256 hash mismatch, wanted %s, got %s",
-			meta.SHA256, sha256,
-		)
+	if meta.Signature != nil {
+		err = verifySignature(toolMeta, *meta.Signature, downloadedToolPath, dir)
+		if err != nil {
+			err = fmt.Errorf("signature verification failed: %w", err)
+			return
+		}
+	}
+
+	if meta.Provenance != nil {
+		err = verifyProvenance(toolMeta, *meta.Provenance, sha256, dir)
+		if err != nil {
+			err = fmt.Errorf("provenance verification failed: %w", err)
+			return
+		}
+	}
+
+	return
+}
+
+// checkDigests verifies all digests of a downloaded tool.
+func checkDigests(downloadedToolPath string, digests map[string]string) (err error) {
+	downloadedTool, err := os.Open(downloadedToolPath)
+	if err != nil {
+		return
+	}
+	defer downloadedTool.Close()
+
+	err = verify.CheckDigests(downloadedTool, digests)
+	return
+}
+
+// verifySignature downloads the signature of a downloaded tool and verifies it
+// with the referenced public key. Keys pinned in the user config take
+// precedence over the keys in the tool metadata.
+func verifySignature(
+	toolMeta api.ToolMeta, signatureMeta api.SignatureMeta,
+	downloadedToolPath string, dir string,
+) (err error) {
+	publicKey, err := lookUpPublicKey(toolMeta, signatureMeta.Key)
+	if err != nil {
+		return
+	}
+
+	signatureDir, err := os.MkdirTemp(dir, "signature-*")
+	if err != nil {
+		return
+	}
+	signatureURL, err := rewriteURL(signatureMeta.URL)
+	if err != nil {
+		return
+	}
+	signaturePath, _, err := downloadURL(signatureURL, signatureDir)
+	if err != nil {
+		return
+	}
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return
+	}
+
+	downloadedTool, err := os.Open(downloadedToolPath)
+	if err != nil {
+		return
+	}
+	defer downloadedTool.Close()
+
+	err = verify.Signature(signatureMeta.Type, publicKey, signature, downloadedTool)
+	return
+}
+
+// verifyProvenance downloads the SLSA provenance of a downloaded tool and
+// verifies it against the builder policy of the tool.
+func verifyProvenance(
+	toolMeta api.ToolMeta, provenanceMeta api.ProvenanceMeta,
+	sha256 string, dir string,
+) (err error) {
+	if toolMeta.Provenance == nil {
+		err = fmt.Errorf("no provenance policy found")
+		return
+	}
+
+	// Without a key, the attestation is still checked, so that keyless
+	// attestations are reported as such
+	var publicKey string
+	if provenanceMeta.Key != "" {
+		publicKey, err = lookUpPublicKey(toolMeta, provenanceMeta.Key)
+		if err != nil {
+			return
+		}
+	}
+
+	provenanceDir, err := os.MkdirTemp(dir, "provenance-*")
+	if err != nil {
+		return
+	}
+	provenanceURL, err := rewriteURL(provenanceMeta.URL)
+	if err != nil {
 		return
 	}
+	provenancePath, _, err := downloadURL(provenanceURL, provenanceDir)
+	if err != nil {
+		return
+	}
+	attestation, err := os.ReadFile(provenancePath)
+	if err != nil {
+		return
+	}
+
+	err = verify.Provenance(
+		attestation, sha256, toolMeta.Provenance.BuilderIDs, publicKey,
+	)
+	return
+}
 
+// lookUpPublicKey returns the public key with the given name. Keys pinned in
+// the user config take precedence over the keys in the tool metadata. Key
+// names are case-insensitive, as the config keys are lowercased when read.
+func lookUpPublicKey(toolMeta api.ToolMeta, keyName string) (publicKey string, err error) {
+	keyName = strings.ToLower(keyName)
+	publicKey = viper.GetStringMapString("PublicKeys")[keyName]
+	if publicKey == "" {
+		for name, key := range toolMeta.PublicKeys {
+			if strings.ToLower(name) == keyName {
+				publicKey = key
+				break
+			}
+		}
+	}
+	if publicKey == "" {
+		err = fmt.Errorf("public key %s could not be found", keyName)
+	}
 	return
 }
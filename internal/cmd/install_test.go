@@ -3,6 +3,7 @@ package cmd_test
 import (
 	"runtime"
 	"testing"
+	"time"
 )
 
 func TestInstallCmd(t *testing.T) {
@@ -16,14 +17,20 @@ Examples:
   # Install a specified version of a tool
   toolctl install kubectl@1.20.13
 
+  # Install the latest version of a tool in a release channel
+  toolctl install kubectl@beta
+
   # Install multiple tools
   toolctl install gh k9s
 
 Flags:
-  -h, --help   help for install
+      --force   install versions even if they have been yanked
+  -h, --help    help for install
 
 Global Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
 
 `
 
@@ -117,6 +124,186 @@ Global Flags:
 			wantOut: `👷 Installing v0.1.0 ...
 🎉 Successfully installed
 `,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool as .deb",
+			cliArgs: []string{"toolctl-test-tool-deb"},
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool-deb",
+					version: "0.1.0",
+					deb:     true,
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool as .rpm",
+			cliArgs: []string{"toolctl-test-tool-rpm"},
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool-rpm",
+					version: "0.1.0",
+					rpm:     true,
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool as .rpm with hardlinked binary",
+			cliArgs: []string{"toolctl-test-tool-rpm"},
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool-rpm",
+					version:     "0.1.0",
+					rpm:         true,
+					rpmHardlink: true,
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool as .rpm with uncompressed payload",
+			cliArgs: []string{"toolctl-test-tool-rpm"},
+			supportedTools: []supportedTool{
+				{
+					name:            "toolctl-test-tool-rpm",
+					version:         "0.1.0",
+					rpm:             true,
+					rpmUncompressed: true,
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with valid signature",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+					signed:  true,
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with valid signature, key name in different case",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "0.1.0",
+					tarGz:         true,
+					signed:        true,
+					publicKeyName: "Test-Key",
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with invalid signature",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:             "toolctl-test-tool",
+					version:          "0.1.0",
+					tarGz:            true,
+					signed:           true,
+					signatureInvalid: true,
+				},
+			},
+			wantErr: true,
+			wantOut: `👷 Installing v0.1.0 ...
+Error: signature verification failed: invalid cosign signature
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with provenance from trusted builder",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:                "toolctl-test-tool",
+					version:             "0.1.0",
+					tarGz:               true,
+					provenanceBuilderID: testBuilderID + "@refs/tags/v1.9.0",
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with provenance from untrusted builder",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:                "toolctl-test-tool",
+					version:             "0.1.0",
+					tarGz:               true,
+					provenanceBuilderID: "https://example.com/builder@v1",
+				},
+			},
+			wantErr: true,
+			wantOut: `👷 Installing v0.1.0 ...
+Error: provenance verification failed: builder https://example.com/builder@v1 is not trusted
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with matching digests",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+					digests: []string{"sha512", "blake2b"},
+				},
+			},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "supported tool with mismatching digest",
+			cliArgs: []string{"toolctl-test-tool"},
+			supportedTools: []supportedTool{
+				{
+					name:           "toolctl-test-tool",
+					version:        "0.1.0",
+					tarGz:          true,
+					digests:        []string{"blake2b", "sha512"},
+					digestMismatch: true,
+				},
+			},
+			wantErr: true,
+			wantOutRegex: `^👷 Installing v0.1.0 ...
+Error: BLAKE2B hash mismatch, wanted 0{128}, got [0-9a-f]{128}
+$`,
 		},
 		// -------------------------------------------------------------------------
 		{
@@ -169,6 +356,391 @@ Global Flags:
 			wantErr: true,
 			wantOut: `👷 Installing v1.0.0 ...
 Error: toolctl-test-tool v1.0.0 could not be found
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with calendar versions",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "2024.03.1",
+					tarGz:         true,
+					versionScheme: "calver",
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v2024.03.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with four-part versions",
+			supportedTools: []supportedTool{
+				{
+					name:          "toolctl-test-tool",
+					version:       "1.2.3.4",
+					tarGz:         true,
+					versionScheme: "four-part",
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@1.2.3.4"},
+			wantOut: `👷 Installing v1.2.3.4 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with yanked version",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.0",
+					tarGz:        true,
+					yankedReason: "broken release",
+				},
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@0.1.0"},
+			wantErr: true,
+			wantOut: `👷 Installing v0.1.0 ...
+Error: toolctl-test-tool v0.1.0 has been yanked (broken release), use --force to install it anyway
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with yanked version, forced",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.0",
+					tarGz:        true,
+					yankedReason: "broken release",
+				},
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@0.1.0", "--force"},
+			wantOut: `👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool released long enough ago",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: "2024-03-15T12:00:00Z",
+				},
+			},
+			config:  map[string]any{"MinReleaseAge": "3d"},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool released too recently",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-5 * time.Hour).UTC().Format(time.RFC3339),
+				},
+			},
+			config:  map[string]any{"MinReleaseAge": "3d"},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOut: "Error: toolctl-test-tool v0.1.1 was released 5 hours ago, " +
+				"but the minimum release age is 3 days, and no older version was released long enough ago\n",
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool released too recently, older version released long enough ago",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.0",
+					tarGz:       true,
+					releaseDate: "2024-03-15T12:00:00Z",
+				},
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-5 * time.Hour).UTC().Format(time.RFC3339),
+				},
+			},
+			apiIndex: true,
+			config:   map[string]any{"MinReleaseAge": "3d"},
+			cliArgs:  []string{"toolctl-test-tool"},
+			wantOut: `⏳ v0.1.1 was released 5 hours ago, but the minimum release age is 3 days
+👷 Installing v0.1.0 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool released too recently, older version yanked",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.0",
+					tarGz:        true,
+					releaseDate:  "2024-03-15T12:00:00Z",
+					yankedReason: "broken release",
+				},
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-5 * time.Hour).UTC().Format(time.RFC3339),
+				},
+			},
+			apiIndex: true,
+			config:   map[string]any{"MinReleaseAge": "3d"},
+			cliArgs:  []string{"toolctl-test-tool"},
+			wantErr:  true,
+			wantOut: "Error: toolctl-test-tool v0.1.1 was released 5 hours ago, " +
+				"but the minimum release age is 3 days, and no older version was released long enough ago\n",
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with pinned version released too recently",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-5 * time.Hour).UTC().Format(time.RFC3339),
+				},
+			},
+			config:  map[string]any{"MinReleaseAge": "3d"},
+			cliArgs: []string{"toolctl-test-tool@0.1.1"},
+			wantOut: `👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool from mirror",
+			supportedTools: []supportedTool{
+				{
+					name:           "toolctl-test-tool",
+					version:        "0.1.1",
+					tarGz:          true,
+					unreachableURL: true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with rewritten URL",
+			supportedTools: []supportedTool{
+				{
+					name:      "toolctl-test-tool",
+					version:   "0.1.1",
+					tarGz:     true,
+					urlPrefix: "https://github.invalid/",
+				},
+			},
+			config: map[string]any{
+				"URLRewrites": []map[string]any{
+					{"regex": `^https://github\.invalid/(.*)$`, "mirror": "$1"},
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool from OCI registry",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+					ociBlob: true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, all download sources fail",
+			supportedTools: []supportedTool{
+				{
+					name:           "toolctl-test-tool",
+					version:        "0.1.1",
+					tarGz:          true,
+					unreachableURL: true,
+					urlPrefix:      "http://127.0.0.1:1/",
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOutRegex: `^👷 Installing v0.1.1 ...
+Error: all download sources failed:
+http://127.0.0.1:1/http://127.0.0.1:\d+/unreachable/toolctl-test-tool.tar.gz: .+connection refused
+http://127.0.0.1:1/http://127.0.0.1:\d+/\w+/\w+/0.1.1/toolctl-test-tool.tar.gz: .+connection refused
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "invalid URL rewrite",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			config: map[string]any{
+				"URLRewrites": []map[string]any{{"prefix": "https://github.com/"}},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOut: `👷 Installing v0.1.1 ...
+Error: invalid URLRewrites: rule 1 needs a mirror and either a prefix or a regex
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with invalid minimum release age",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			config:  map[string]any{"MinReleaseAge": "3 days"},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOut: `Error: invalid MinReleaseAge: time: unknown unit " days" in duration "3 days"
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "invalid HTTP read timeout",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			config:  map[string]any{"HTTPReadTimeout": "soon"},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOut: `Error: invalid HTTPReadTimeout: time: invalid duration "soon"
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool in release channel",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.2.0-beta.1",
+					tarGz:        true,
+					versionRegex: `v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`,
+				},
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.1",
+					tarGz:        true,
+					versionRegex: `v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`,
+					channels:     map[string]string{"beta": "0.2.0-beta.1"},
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@beta"},
+			wantOut: `👷 Installing v0.2.0-beta.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool without release channel configured",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.2.0-beta.1",
+					tarGz:   true,
+				},
+				{
+					name:     "toolctl-test-tool",
+					version:  "0.1.1",
+					tarGz:    true,
+					channels: map[string]string{"beta": "0.2.0-beta.1"},
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool with release channel configured",
+			supportedTools: []supportedTool{
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.2.0-beta.1",
+					tarGz:        true,
+					versionRegex: `v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`,
+				},
+				{
+					name:         "toolctl-test-tool",
+					version:      "0.1.1",
+					tarGz:        true,
+					versionRegex: `v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`,
+					channels:     map[string]string{"beta": "0.2.0-beta.1"},
+				},
+			},
+			config: map[string]any{
+				"Channels": map[string]string{"toolctl-test-tool": "beta"},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `👷 Installing v0.2.0-beta.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool in unknown release channel",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool@nightly"},
+			wantErr: true,
+			wantOut: `Error: toolctl-test-tool nightly channel could not be found
 `,
 		},
 		// -------------------------------------------------------------------------
@@ -313,6 +885,23 @@ exit 1
 Error: installation failed: expected v0.1.2, but installed binary reported v0.1.1
 `,
 		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool that is not an executable for the platform",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.0",
+					tarGz:       true,
+					shellScript: true,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOutRegex: `^👷 Installing v0.1.0 ...
+Error: toolctl-test-tool is not an executable for ` + runtime.GOOS + "/" + runtime.GOARCH + `: not an? (ELF|Mach-O) file
+$`,
+		},
 	}
 
 	runInstallUpgradeTests(t, tests, "install")
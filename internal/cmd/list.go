@@ -57,7 +57,7 @@ func newRunList(
 	toolctlWriter io.Writer, localAPIFS afero.Fs,
 ) func(*cobra.Command, []string) (err error) {
 	return func(cmd *cobra.Command, _ []string) (err error) {
-		toolctlAPI, err := api.New(localAPIFS, cmd, api.Remote)
+		toolctlAPI, err := api.New(localAPIFS, cmd)
 		if err != nil {
 			return err
 		}
@@ -103,14 +103,8 @@ func list(
 	}
 
 	if markdownFlag {
-		var localFlag bool
-		localFlag, err = cmd.Flags().GetBool("local")
-		if err != nil {
-			return
-		}
-
-		if !localFlag {
-			return fmt.Errorf("--markdown also requires --local")
+		if toolctlAPI.LocalAPIBasePath() == "" {
+			return fmt.Errorf("--markdown also requires a local registry, e.g. --local")
 		}
 
 		return printMarkdown(toolctlWriter, toolctlAPI, toolNames)
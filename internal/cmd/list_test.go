@@ -31,7 +31,9 @@ Flags:
   -h, --help   help for list
 
 Global Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
 `
 
 	tests := []test{
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/httpclient"
+)
+
+// urlRewrite is a rule from the URLRewrites config that redirects downloads to
+// a mirror. URLs starting with Prefix get the prefix replaced by Mirror. If
+// Regex is set instead, all matches are replaced by Mirror, which may refer to
+// submatches like $1.
+type urlRewrite struct {
+	Prefix string
+	Regex  string
+	Mirror string
+}
+
+// loadURLRewrites loads the URL rewrite rules from the config.
+func loadURLRewrites() (rewrites []urlRewrite, regexes []*regexp.Regexp, err error) {
+	err = viper.UnmarshalKey("URLRewrites", &rewrites)
+	if err != nil {
+		err = fmt.Errorf("invalid URLRewrites: %w", err)
+		return
+	}
+
+	regexes = make([]*regexp.Regexp, len(rewrites))
+	for i, rewrite := range rewrites {
+		if (rewrite.Prefix == "") == (rewrite.Regex == "") || rewrite.Mirror == "" {
+			err = fmt.Errorf(
+				"invalid URLRewrites: rule %d needs a mirror and either a prefix or a regex", i+1,
+			)
+			return
+		}
+		if rewrite.Regex != "" {
+			regexes[i], err = regexp.Compile(rewrite.Regex)
+			if err != nil {
+				err = fmt.Errorf("invalid URLRewrites: rule %d: %w", i+1, err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// rewriteURL applies the first matching URL rewrite rule to a URL.
+func rewriteURL(url string) (rewrittenURL string, err error) {
+	rewrites, regexes, err := loadURLRewrites()
+	if err != nil {
+		return
+	}
+
+	for i, rewrite := range rewrites {
+		if regexes[i] != nil {
+			if regexes[i].MatchString(url) {
+				return regexes[i].ReplaceAllString(url, rewrite.Mirror), nil
+			}
+			continue
+		}
+		if rest, found := strings.CutPrefix(url, rewrite.Prefix); found {
+			return rewrite.Mirror + rest, nil
+		}
+	}
+	return url, nil
+}
+
+// downloadSources returns the URLs that a tool version can be downloaded from,
+// in the order in which they should be tried: the URL of the version, followed
+// by its mirrors, all with the URL rewrite rules applied.
+func downloadSources(meta api.ToolPlatformVersionMeta) (urls []string, err error) {
+	for _, url := range append([]string{meta.URL}, meta.Mirrors...) {
+		url, err = rewriteURL(url)
+		if err != nil {
+			return
+		}
+		if !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+	}
+	return
+}
+
+// downloadFromSources downloads a tool version from the first source that
+// works and passes the checksum verification.
+func downloadFromSources(
+	meta api.ToolPlatformVersionMeta, dir string,
+) (downloadedToolPath string, sha256 string, err error) {
+	urls, err := downloadSources(meta)
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	for _, url := range urls {
+		downloadedToolPath, sha256, err = downloadAndCheck(url, meta, dir)
+		if err == nil {
+			return
+		}
+		if len(urls) == 1 {
+			return
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", httpclient.RedactURL(url), err))
+		if downloadedToolPath != "" {
+			_ = os.Remove(downloadedToolPath)
+		}
+	}
+
+	err = fmt.Errorf("all download sources failed:\n%w", errors.Join(errs...))
+	downloadedToolPath, sha256 = "", ""
+	return
+}
+
+// downloadAndCheck downloads a tool version from a URL and verifies its
+// checksums.
+func downloadAndCheck(
+	url string, meta api.ToolPlatformVersionMeta, dir string,
+) (downloadedToolPath string, sha256 string, err error) {
+	downloadedToolPath, sha256, err = downloadURL(url, dir)
+	if err != nil {
+		return
+	}
+
+	// The SHA256 key may be omitted if other digests are present
+	if (meta.SHA256 != "" || len(meta.Digests) == 0) && sha256 != meta.SHA256 {
+		err = fmt.Errorf(
+			"SHA256 hash mismatch, wanted %s, got %s",
+			meta.SHA256, sha256,
+		)
+		return
+	}
+
+	if len(meta.Digests) > 0 {
+		err = checkDigests(downloadedToolPath, meta.Digests)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
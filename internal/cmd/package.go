@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mholt/archives"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+var (
+	arMagic        = []byte("!<arch>\n")
+	rpmLeadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+)
+
+const (
+	arHeaderSize   = 60
+	rpmLeadSize    = 96
+	cpioHeaderSize = 110
+	cpioTrailer    = "TRAILER!!!"
+)
+
+// isPackageFile checks if a file is a Linux package based on its extension.
+func isPackageFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".deb") ||
+		strings.HasSuffix(filePath, ".rpm")
+}
+
+// extractFromPackage extracts a tool binary from a .deb or .rpm package,
+// without relying on dpkg or rpm being installed.
+func extractFromPackage(tool api.Tool, packagePath, dir string) (string, error) {
+	packageFile, err := os.Open(packagePath)
+	if err != nil {
+		return "", err
+	}
+	defer packageFile.Close()
+
+	var extractedToolPath string
+	if strings.HasSuffix(packagePath, ".deb") {
+		extractedToolPath, err = extractFromDeb(tool, packageFile, dir)
+	} else {
+		extractedToolPath, err = extractFromRPM(tool, packageFile, dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract package: %w", err)
+	}
+	if extractedToolPath == "" {
+		return "", fmt.Errorf("%s binary could not be found in package", tool.Name)
+	}
+
+	return extractedToolPath, nil
+}
+
+// extractFromDeb extracts a tool binary from the data archive of a .deb
+// package, which is an ar archive containing (compressed) tar archives.
+func extractFromDeb(tool api.Tool, debFile io.Reader, dir string) (string, error) {
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(debFile, magic); err != nil || !bytes.Equal(magic, arMagic) {
+		return "", fmt.Errorf("not a Debian package")
+	}
+
+	for {
+		header := make([]byte, arHeaderSize)
+		if _, err := io.ReadFull(debFile, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("data archive could not be found")
+			}
+			return "", err
+		}
+
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid ar header for %s", name)
+		}
+
+		member := io.LimitReader(debFile, size)
+		if strings.HasPrefix(name, "data.tar") {
+			return extractFromTarStream(tool, name, member, dir)
+		}
+
+		// Members are aligned to an even number of bytes
+		if _, err := io.CopyN(io.Discard, debFile, size+size%2); err != nil {
+			return "", err
+		}
+	}
+}
+
+// extractFromTarStream extracts a tool binary from a possibly compressed tar
+// stream.
+func extractFromTarStream(
+	tool api.Tool, name string, stream io.Reader, dir string,
+) (string, error) {
+	ctx := context.Background()
+
+	format, stream, err := archives.Identify(ctx, name, stream)
+	if err != nil {
+		return "", err
+	}
+	extractor, ok := format.(archives.Extractor)
+	if !ok {
+		return "", fmt.Errorf("unsupported data archive: %s", name)
+	}
+
+	var extractedToolPath string
+	binaryLocatedError := errors.New("binary located")
+
+	err = extractor.Extract(ctx, stream, func(_ context.Context, f archives.FileInfo) error {
+		if !f.Mode().IsRegular() || !isMatchingBinary(tool, f.NameInArchive) {
+			return nil
+		}
+
+		src, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		extractedToolPath, err = writeBinary(src, f.NameInArchive, dir)
+		if err != nil {
+			return err
+		}
+		return binaryLocatedError
+	})
+	if err != nil && !errors.Is(err, binaryLocatedError) {
+		return "", err
+	}
+
+	return extractedToolPath, nil
+}
+
+// extractFromRPM extracts a tool binary from the cpio payload of an .rpm
+// package, which is usually compressed.
+func extractFromRPM(tool api.Tool, rpmFile io.Reader, dir string) (string, error) {
+	reader := bufio.NewReader(rpmFile)
+
+	lead := make([]byte, rpmLeadSize)
+	if _, err := io.ReadFull(reader, lead); err != nil || !bytes.Equal(lead[:4], rpmLeadMagic) {
+		return "", fmt.Errorf("not an RPM package")
+	}
+
+	// Skip the signature header, which is padded to a multiple of 8 bytes,
+	// and the main header
+	if err := skipRPMHeader(reader, true); err != nil {
+		return "", err
+	}
+	if err := skipRPMHeader(reader, false); err != nil {
+		return "", err
+	}
+
+	// The payload may also be stored uncompressed
+	if magic, err := reader.Peek(6); err == nil && isCpioMagic(string(magic)) {
+		return extractFromCpio(tool, reader, dir)
+	}
+
+	format, payload, err := archives.Identify(context.Background(), "", reader)
+	if err != nil {
+		return "", fmt.Errorf("unsupported RPM payload: %w", err)
+	}
+	decompressor, ok := format.(archives.Decompressor)
+	if !ok {
+		return "", fmt.Errorf("unsupported RPM payload compression")
+	}
+	cpioReader, err := decompressor.OpenReader(payload)
+	if err != nil {
+		return "", err
+	}
+	defer cpioReader.Close()
+
+	return extractFromCpio(tool, cpioReader, dir)
+}
+
+// skipRPMHeader skips an RPM header structure.
+func skipRPMHeader(reader io.Reader, padded bool) error {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(reader, intro); err != nil || !bytes.Equal(intro[:4], rpmHeaderMagic) {
+		return fmt.Errorf("invalid RPM header")
+	}
+
+	indexCount := int64(binary.BigEndian.Uint32(intro[8:12]))
+	storeSize := int64(binary.BigEndian.Uint32(intro[12:16]))
+	size := indexCount*16 + storeSize
+	if padded && size%8 != 0 {
+		size += 8 - size%8
+	}
+
+	_, err := io.CopyN(io.Discard, reader, size)
+	return err
+}
+
+// cpioFileKey identifies a file in a cpio archive, so that hardlinks to the
+// same file can be recognized.
+type cpioFileKey struct {
+	devMajor, devMinor, ino uint64
+}
+
+// extractFromCpio extracts a tool binary from a cpio archive in the "newc"
+// format used by RPM. The data of hardlinked files is only stored with the
+// last of their entries, so if the binary is a hardlink without data, the data
+// of the last entry of the same file is extracted instead.
+func extractFromCpio(tool api.Tool, cpioReader io.Reader, dir string) (string, error) {
+	var hardlinkName string
+	var hardlinkKey cpioFileKey
+
+	for {
+		header := make([]byte, cpioHeaderSize)
+		if _, err := io.ReadFull(cpioReader, header); err != nil {
+			return "", err
+		}
+		if !isCpioMagic(string(header[0:6])) {
+			return "", fmt.Errorf("invalid cpio header")
+		}
+
+		var errs []error
+		field := func(offset int) uint64 {
+			value, err := strconv.ParseUint(string(header[offset:offset+8]), 16, 32)
+			errs = append(errs, err)
+			return value
+		}
+		key := cpioFileKey{ino: field(6), devMajor: field(62), devMinor: field(70)}
+		mode, nlink := field(14), field(38)
+		fileSize, nameSize := int64(field(54)), int64(field(94))
+		if err := errors.Join(errs...); err != nil {
+			return "", fmt.Errorf("invalid cpio header: %w", err)
+		}
+
+		// The name is padded so that header and name are aligned to 4 bytes
+		name := make([]byte, nameSize+cpioPadding(cpioHeaderSize+nameSize))
+		if _, err := io.ReadFull(cpioReader, name); err != nil {
+			return "", err
+		}
+		fileName := string(bytes.TrimRight(name, "\x00"))
+		if fileName == cpioTrailer {
+			if hardlinkName != "" {
+				return "", fmt.Errorf("data of hardlinked %s could not be found", hardlinkName)
+			}
+			return "", nil
+		}
+
+		data := io.LimitReader(cpioReader, fileSize)
+		if mode&0170000 == 0100000 {
+			switch {
+			case hardlinkName != "" && key == hardlinkKey && fileSize > 0:
+				return writeBinary(data, hardlinkName, dir)
+			case hardlinkName == "" && isMatchingBinary(tool, fileName):
+				if fileSize == 0 && nlink > 1 {
+					hardlinkName, hardlinkKey = fileName, key
+				} else {
+					return writeBinary(data, fileName, dir)
+				}
+			}
+		}
+
+		if _, err := io.CopyN(io.Discard, cpioReader, fileSize+cpioPadding(fileSize)); err != nil {
+			return "", err
+		}
+	}
+}
+
+// isCpioMagic checks if a magic number is one of the "newc" cpio format.
+func isCpioMagic(magic string) bool {
+	return magic == "070701" || magic == "070702"
+}
+
+// cpioPadding returns the number of bytes needed to align size to 4 bytes.
+func cpioPadding(size int64) int64 {
+	return (4 - size%4) % 4
+}
+
+// writeBinary writes a binary read from src to a file in destDir, named
+// after the base name of srcPath.
+func writeBinary(src io.Reader, srcPath, destDir string) (string, error) {
+	destPath := filepath.Join(destDir, filepath.Base(srcPath))
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return "", err
+	}
+	defer dest.Close()
+
+	if _, err := io.Copy(dest, src); err != nil {
+		return "", err
+	}
+
+	return destPath, nil
+}
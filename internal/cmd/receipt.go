@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/toolctl/toolctl/internal/sysutil"
+	"gopkg.in/yaml.v3"
+)
+
+// receipt records what toolctl installed, so that the installation can be
+// checked later on.
+type receipt struct {
+	Name         string
+	Version      string
+	URL          string
+	SHA256       string
+	BinarySHA256 string    `yaml:"binarySHA256"`
+	InstallPath  string    `yaml:"installPath"`
+	InstalledAt  time.Time `yaml:"installedAt"`
+}
+
+// receiptPath returns the path of the receipt file for the given tool.
+func receiptPath(toolName string) (path string, err error) {
+	receiptsDir, err := sysutil.RequireConfigString("ReceiptsDir")
+	if err != nil {
+		return
+	}
+	path = filepath.Join(receiptsDir, toolName+".yaml")
+	return
+}
+
+// loadReceipt loads the receipt for the given tool, if there is one.
+func loadReceipt(toolName string) (found bool, r receipt, err error) {
+	path, err := receiptPath(toolName)
+	if err != nil {
+		return
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	err = yaml.Unmarshal(contents, &r)
+	if err != nil {
+		return
+	}
+	found = true
+
+	return
+}
+
+// saveReceipt saves the receipt for a tool, replacing any previous receipt.
+func saveReceipt(r receipt) (err error) {
+	path, err := receiptPath(r.Name)
+	if err != nil {
+		return
+	}
+
+	yamlBuffer := &bytes.Buffer{}
+	yamlEncoder := yaml.NewEncoder(yamlBuffer)
+	yamlEncoder.SetIndent(2)
+	err = yamlEncoder.Encode(r)
+	if err != nil {
+		return
+	}
+	err = yamlEncoder.Close()
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(path, yamlBuffer.Bytes(), 0644)
+	return
+}
+
+// receiptToolNames returns the names of all tools that have a receipt.
+func receiptToolNames() (toolNames []string, err error) {
+	receiptsDir, err := sysutil.RequireConfigString("ReceiptsDir")
+	if err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(receiptsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".yaml") {
+			toolNames = append(toolNames, strings.TrimSuffix(entry.Name(), ".yaml"))
+		}
+	}
+
+	return
+}
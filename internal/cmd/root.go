@@ -25,8 +25,9 @@ func NewRootCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
 
   # Upgrade supported tools
   toolctl upgrade`,
-		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 			cmd.SilenceUsage = true
+			return configureHTTPClient(cmd.Context())
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			if versionFlag {
@@ -45,6 +46,11 @@ func NewRootCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
 		&cfgFile, "config", "", "path of the config file (default is $HOME/.config/toolctl/config.yaml)",
 	)
 
+	rootCmd.PersistentFlags().Bool("refresh", false, "revalidate all cached API responses")
+	rootCmd.PersistentFlags().String(
+		"registry", "", "URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)",
+	)
+
 	// Hidden persistent flags
 	rootCmd.PersistentFlags().Bool("local", false, "Use the local API")
 	err := rootCmd.PersistentFlags().MarkHidden("local")
@@ -53,10 +59,13 @@ func NewRootCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
 	}
 
 	// Commands
+	rootCmd.AddCommand(newAuditCmd(toolctlWriter, localAPIFS))
 	rootCmd.AddCommand(newInfoCmd(toolctlWriter, localAPIFS))
 	rootCmd.AddCommand(newInstallCmd(toolctlWriter, localAPIFS))
 	rootCmd.AddCommand(newListCmd(toolctlWriter, localAPIFS))
+	rootCmd.AddCommand(newSBOMCmd(toolctlWriter, localAPIFS))
 	rootCmd.AddCommand(newUpgradeCmd(toolctlWriter, localAPIFS))
+	rootCmd.AddCommand(newVerifyCmd(toolctlWriter, localAPIFS))
 	rootCmd.AddCommand(newVersionCmd(toolctlWriter))
 
 	// Hidden commands
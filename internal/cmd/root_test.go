@@ -23,18 +23,23 @@ Examples:
   toolctl upgrade
 
 Available Commands:
+  audit       Check installed tools for known vulnerabilities
   completion  Generate the autocompletion script for the specified shell
   help        Help about any command
   info        Get information about tools
   install     Install tools
   list        List the tools
+  sbom        Generate an SBOM of the installed tools
   upgrade     Upgrade tools
+  verify      Verify that installed tools have not been modified
   version     Display the version of toolctl
 
 Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
-  -h, --help            help for toolctl
-      --version         display the version of toolctl
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+  -h, --help              help for toolctl
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
+      --version           display the version of toolctl
 
 Use "toolctl [command] --help" for more information about a command.
 `
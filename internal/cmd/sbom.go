@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+var sbomFormatFlag string
+
+// sbomComponent is a format-independent description of an installed tool.
+type sbomComponent struct {
+	Name        string
+	Version     string
+	Description string
+	Homepage    string
+	URL         string
+	Digests     map[string]string
+}
+
+// cycloneDXHashAlgorithms maps digest algorithms to their CycloneDX names.
+var cycloneDXHashAlgorithms = map[string]string{
+	"blake2b": "BLAKE2b-512",
+	"sha256":  "SHA-256",
+	"sha512":  "SHA-512",
+}
+
+// spdxChecksumAlgorithms maps digest algorithms to their SPDX names.
+var spdxChecksumAlgorithms = map[string]string{
+	"blake2b": "BLAKE2b-512",
+	"sha256":  "SHA256",
+	"sha512":  "SHA512",
+}
+
+type cycloneDXBOM struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string         `json:"timestamp"`
+	Tools     cycloneDXTools `json:"tools"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Description        string                       `json:"description,omitempty"`
+	Hashes             []cycloneDXHash              `json:"hashes,omitempty"`
+	ExternalReferences []cycloneDXExternalReference `json:"externalReferences,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string         `json:"name"`
+	SPDXID           string         `json:"SPDXID"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Homepage         string         `json:"homepage,omitempty"`
+	Description      string         `json:"description,omitempty"`
+	Checksums        []spdxChecksum `json:"checksums,omitempty"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	LicenseDeclared  string         `json:"licenseDeclared"`
+	CopyrightText    string         `json:"copyrightText"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func newSBOMCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
+	var sbomCmd = &cobra.Command{
+		Use:   "sbom",
+		Short: "Generate an SBOM of the installed tools",
+		Example: `  # Generate a CycloneDX SBOM of the installed tools
+  toolctl sbom
+
+  # Generate an SPDX SBOM of the installed tools
+  toolctl sbom --format spdx`,
+		Args: cobra.NoArgs,
+		RunE: newRunSBOM(toolctlWriter, localAPIFS),
+	}
+
+	// Flags
+	sbomCmd.Flags().StringVar(
+		&sbomFormatFlag, "format", "cyclonedx",
+		"format of the SBOM, either cyclonedx or spdx",
+	)
+
+	return sbomCmd
+}
+
+func newRunSBOM(
+	toolctlWriter io.Writer, localAPIFS afero.Fs,
+) func(*cobra.Command, []string) (err error) {
+	return func(cmd *cobra.Command, _ []string) (err error) {
+		if sbomFormatFlag != "cyclonedx" && sbomFormatFlag != "spdx" {
+			return fmt.Errorf(
+				"unsupported SBOM format %s, supported are: cyclonedx, spdx",
+				sbomFormatFlag,
+			)
+		}
+
+		toolctlAPI, err := api.New(localAPIFS, cmd)
+		if err != nil {
+			return err
+		}
+
+		components, err := sbomComponents(toolctlAPI)
+		if err != nil {
+			return
+		}
+
+		var document any
+		if sbomFormatFlag == "spdx" {
+			document, err = newSPDXDocument(components)
+		} else {
+			document, err = newCycloneDXBOM(components)
+		}
+		if err != nil {
+			return
+		}
+
+		jsonEncoder := json.NewEncoder(toolctlWriter)
+		jsonEncoder.SetIndent("", "  ")
+		err = jsonEncoder.Encode(document)
+
+		return
+	}
+}
+
+// sbomComponents collects the SBOM components of all tools that toolctl has
+// installed, according to their receipts. Tools that were installed by other
+// means, e.g. a package manager, are not managed by toolctl and left out.
+func sbomComponents(toolctlAPI api.ToolctlAPI) (components []sbomComponent, err error) {
+	toolNames, err := receiptToolNames()
+	if err != nil {
+		return
+	}
+	slices.Sort(toolNames)
+
+	for _, toolName := range toolNames {
+		var found bool
+		var r receipt
+		found, r, err = loadReceipt(toolName)
+		if err != nil {
+			return
+		}
+		if !found {
+			continue
+		}
+
+		// Tools that were removed since are not installed anymore
+		_, err = os.Stat(r.InstallPath)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return
+			}
+			err = nil
+			continue
+		}
+
+		var component sbomComponent
+		component, err = newSBOMComponent(
+			toolctlAPI, api.Tool{
+				Name:    toolName,
+				OS:      runtime.GOOS,
+				Arch:    runtime.GOARCH,
+				Version: r.Version,
+			},
+			r,
+		)
+		if err != nil {
+			return
+		}
+		components = append(components, component)
+	}
+
+	return
+}
+
+// newSBOMComponent describes an installed tool, using its receipt, and the
+// metadata of the API, if the tool and version are still in it.
+func newSBOMComponent(
+	toolctlAPI api.ToolctlAPI, tool api.Tool, r receipt,
+) (component sbomComponent, err error) {
+	component = sbomComponent{
+		Name:    tool.Name,
+		Version: tool.Version,
+		URL:     r.URL,
+		Digests: map[string]string{},
+	}
+	if r.SHA256 != "" {
+		component.Digests["sha256"] = r.SHA256
+	}
+
+	toolMeta, err := api.GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		if errors.Is(err, api.NotFoundError{}) {
+			err = nil
+		}
+		return
+	}
+	component.Description = toolMeta.Description
+	component.Homepage = toolMeta.Homepage
+
+	meta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+	if err != nil {
+		if errors.Is(err, api.NotFoundError{}) {
+			err = nil
+		}
+		return
+	}
+	if component.URL == "" {
+		component.URL = meta.URL
+	}
+	for algorithm, digest := range meta.Digests {
+		if _, ok := component.Digests[algorithm]; !ok {
+			component.Digests[algorithm] = digest
+		}
+	}
+	if _, ok := component.Digests["sha256"]; !ok && meta.SHA256 != "" {
+		component.Digests["sha256"] = meta.SHA256
+	}
+
+	return
+}
+
+// newCycloneDXBOM creates a CycloneDX 1.5 BOM from the given components.
+func newCycloneDXBOM(components []sbomComponent) (bom cycloneDXBOM, err error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return
+	}
+
+	bom = cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid,
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: cycloneDXTools{
+				Components: []cycloneDXComponent{
+					{Type: "application", Name: "toolctl", Version: gitVersion},
+				},
+			},
+		},
+		Components: []cycloneDXComponent{},
+	}
+
+	for _, component := range components {
+		cycloneDXComponent := cycloneDXComponent{
+			Type:        "application",
+			BOMRef:      component.Name,
+			Name:        component.Name,
+			Version:     component.Version,
+			Description: component.Description,
+		}
+		if component.Version != "" {
+			cycloneDXComponent.BOMRef += "@" + component.Version
+		}
+
+		for _, algorithm := range sortedKeys(component.Digests) {
+			alg, ok := cycloneDXHashAlgorithms[algorithm]
+			if !ok {
+				continue
+			}
+			cycloneDXComponent.Hashes = append(cycloneDXComponent.Hashes, cycloneDXHash{
+				Alg: alg, Content: component.Digests[algorithm],
+			})
+		}
+
+		if component.Homepage != "" {
+			cycloneDXComponent.ExternalReferences = append(
+				cycloneDXComponent.ExternalReferences,
+				cycloneDXExternalReference{Type: "website", URL: component.Homepage},
+			)
+		}
+		if component.URL != "" {
+			cycloneDXComponent.ExternalReferences = append(
+				cycloneDXComponent.ExternalReferences,
+				cycloneDXExternalReference{Type: "distribution", URL: component.URL},
+			)
+		}
+
+		bom.Components = append(bom.Components, cycloneDXComponent)
+	}
+
+	return
+}
+
+// spdxIDInvalidChars matches the characters that are not allowed in SPDX IDs.
+var spdxIDInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9.-]`)
+
+// newSPDXDocument creates an SPDX 2.3 document from the given components.
+func newSPDXDocument(components []sbomComponent) (document spdxDocument, err error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return
+	}
+
+	document = spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "toolctl-installed-tools",
+		DocumentNamespace: "https://toolctl.io/spdx/toolctl-installed-tools-" + uuid,
+		CreationInfo: spdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: toolctl-" + gitVersion},
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+
+	for _, component := range components {
+		spdxID := "SPDXRef-Package-" + spdxIDInvalidChars.ReplaceAllString(component.Name, "-")
+
+		spdxPackage := spdxPackage{
+			Name:             component.Name,
+			SPDXID:           spdxID,
+			VersionInfo:      component.Version,
+			DownloadLocation: "NOASSERTION",
+			Homepage:         component.Homepage,
+			Description:      component.Description,
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		}
+		if component.URL != "" {
+			spdxPackage.DownloadLocation = component.URL
+		}
+
+		for _, algorithm := range sortedKeys(component.Digests) {
+			spdxAlgorithm, ok := spdxChecksumAlgorithms[algorithm]
+			if !ok {
+				continue
+			}
+			spdxPackage.Checksums = append(spdxPackage.Checksums, spdxChecksum{
+				Algorithm: spdxAlgorithm, ChecksumValue: component.Digests[algorithm],
+			})
+		}
+
+		document.Packages = append(document.Packages, spdxPackage)
+		document.Relationships = append(document.Relationships, spdxRelationship{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxID,
+		})
+	}
+
+	return
+}
+
+// newUUID generates a random (version 4) UUID.
+func newUUID() (uuid string, err error) {
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	uuid = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	return
+}
+
+// sortedKeys returns the keys of the given map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
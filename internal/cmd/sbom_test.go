@@ -0,0 +1,161 @@
+package cmd_test
+
+import (
+	"testing"
+)
+
+func TestSBOMCmd(t *testing.T) {
+	supportedTools := []supportedTool{
+		{
+			name:    "toolctl-test-tool",
+			version: "0.1.0",
+			tarGz:   true,
+		},
+		{
+			name:    "toolctl-other-test-tool",
+			version: "0.2.0",
+			tarGz:   true,
+		},
+	}
+	preinstalledTools := []preinstalledTool{
+		{
+			name: "toolctl-test-tool",
+			fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+		},
+		{
+			name: "toolctl-other-test-tool",
+			fileContents: `#!/bin/sh
+echo "v0.2.0"
+`,
+		},
+	}
+	receipts := []testReceipt{
+		{
+			name:    "toolctl-test-tool",
+			version: "0.1.0",
+		},
+	}
+
+	tests := []test{
+		{
+			name:              "CycloneDX",
+			cliArgs:           []string{},
+			supportedTools:    supportedTools,
+			preinstalledTools: preinstalledTools,
+			receipts:          receipts,
+			wantOutRegex: `^{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": "urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}",
+  "version": 1,
+  "metadata": {
+    "timestamp": "\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ",
+    "tools": {
+      "components": \[
+        {
+          "type": "application",
+          "name": "toolctl",
+          "version": "v0.0.0-dev"
+        }
+      \]
+    }
+  },
+  "components": \[
+    {
+      "type": "application",
+      "bom-ref": "toolctl-test-tool@0.1.0",
+      "name": "toolctl-test-tool",
+      "version": "0.1.0",
+      "description": "toolctl test tool",
+      "hashes": \[
+        {
+          "alg": "SHA-256",
+          "content": "[0-9a-f]{64}"
+        }
+      \],
+      "externalReferences": \[
+        {
+          "type": "website",
+          "url": "https://toolctl.io/"
+        },
+        {
+          "type": "distribution",
+          "url": "http://.+/toolctl-test-tool.tar.gz"
+        }
+      \]
+    }
+  \]
+}
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:              "SPDX",
+			cliArgs:           []string{"--format", "spdx"},
+			supportedTools:    supportedTools,
+			preinstalledTools: preinstalledTools,
+			receipts:          receipts,
+			wantOutRegex: `^{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "toolctl-installed-tools",
+  "documentNamespace": "https://toolctl.io/spdx/toolctl-installed-tools-[0-9a-f-]{36}",
+  "creationInfo": {
+    "created": "\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ",
+    "creators": \[
+      "Tool: toolctl-v0.0.0-dev"
+    \]
+  },
+  "packages": \[
+    {
+      "name": "toolctl-test-tool",
+      "SPDXID": "SPDXRef-Package-toolctl-test-tool",
+      "versionInfo": "0.1.0",
+      "downloadLocation": "http://.+/toolctl-test-tool.tar.gz",
+      "filesAnalyzed": false,
+      "homepage": "https://toolctl.io/",
+      "description": "toolctl test tool",
+      "checksums": \[
+        {
+          "algorithm": "SHA256",
+          "checksumValue": "[0-9a-f]{64}"
+        }
+      \],
+      "licenseConcluded": "NOASSERTION",
+      "licenseDeclared": "NOASSERTION",
+      "copyrightText": "NOASSERTION"
+    }
+  \],
+  "relationships": \[
+    {
+      "spdxElementId": "SPDXRef-DOCUMENT",
+      "relationshipType": "DESCRIBES",
+      "relatedSpdxElement": "SPDXRef-Package-toolctl-test-tool"
+    }
+  \]
+}
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:              "tools without receipt",
+			cliArgs:           []string{},
+			supportedTools:    supportedTools,
+			preinstalledTools: preinstalledTools,
+			wantOutRegex:      `(?s)^{\n.+\n  "components": \[\]\n}\n$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name:    "unsupported format",
+			cliArgs: []string{"--format", "xml"},
+			wantErr: true,
+			wantOut: `Error: unsupported SBOM format xml, supported are: cyclonedx, spdx
+`,
+		},
+	}
+
+	runInstallUpgradeTests(t, tests, "sbom")
+}
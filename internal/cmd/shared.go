@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"errors"
@@ -13,13 +14,16 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
-	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver"
 	"github.com/mholt/archives"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/api"
+	"github.com/toolctl/toolctl/internal/httpclient"
+	"github.com/toolctl/toolctl/internal/oci"
 )
 
 // ArgToTool converts a CLI argument into a Tool object, supporting optional version parsing.
@@ -68,6 +72,18 @@ func CalculateSHA256(body io.Reader) (sha string, err error) {
 	return
 }
 
+// calculateFileSHA256 computes the SHA256 hash of a file.
+func calculateFileSHA256(filePath string) (sha string, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	sha, err = CalculateSHA256(file)
+	return
+}
+
 // checkArgs validates positional arguments for a Cobra command.
 func checkArgs(worksWithoutArgs bool) cobra.PositionalArgs {
 	return func(_ *cobra.Command, args []string) (err error) {
@@ -82,25 +98,20 @@ func checkArgs(worksWithoutArgs bool) cobra.PositionalArgs {
 func downloadURL(url string, dir string) (
 	downloadedFilePath string, sha256 string, err error,
 ) {
-	resp, err := http.Get(url)
+	body, fileName, digest, err := openURL(url)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		return
-	}
+	defer body.Close()
 
-	downloadedFilePath = filepath.Join(dir, path.Base(url))
+	downloadedFilePath = filepath.Join(dir, fileName)
 
 	// Create the file
 	downloadedFile, err := os.Create(downloadedFilePath)
 	if err != nil {
 		return
 	}
-	_, err = io.Copy(downloadedFile, resp.Body)
+	_, err = io.Copy(downloadedFile, body)
 	if err != nil {
 		return
 	}
@@ -119,10 +130,52 @@ func downloadURL(url string, dir string) (
 		return
 	}
 	err = downloadedFile.Close()
+	if err != nil {
+		return
+	}
 
+	if digest != "" && digest != "sha256:"+sha256 {
+		err = fmt.Errorf("digest mismatch, wanted %s, got sha256:%s", digest, sha256)
+	}
 	return
 }
 
+// openURL opens a download. Besides HTTP URLs, blobs in OCI registries are
+// supported, e.g. oci://registry.example.com/tools@sha256:<hex>#k9s.tar.gz.
+// As blobs have no names, the file name is taken from the fragment, and the
+// digest of the blob is returned to be verified.
+func openURL(url string) (body io.ReadCloser, fileName string, digest string, err error) {
+	if !oci.IsReference(url) {
+		var resp *http.Response
+		resp, err = httpclient.Get(url)
+		if err != nil {
+			return
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			return
+		}
+		return resp.Body, path.Base(url), "", nil
+	}
+
+	ref, err := oci.ParseReference(url)
+	if err != nil {
+		return
+	}
+	if ref.Digest == "" {
+		err = fmt.Errorf("invalid download URL %s: OCI downloads must refer to a digest", url)
+		return
+	}
+
+	fileName = ref.FileName
+	if fileName == "" {
+		fileName = strings.TrimPrefix(ref.Digest, "sha256:")
+	}
+	body, err = oci.NewClient(ref).OpenBlob(ref.Digest)
+	return body, path.Base(fileName), ref.Digest, err
+}
+
 // extractDownloadedTool extracts a tool from an archive or verifies its binary.
 func extractDownloadedTool(tool api.Tool, downloadedToolPath string) (string, error) {
 	dir := filepath.Dir(downloadedToolPath)
@@ -134,6 +187,12 @@ func extractDownloadedTool(tool api.Tool, downloadedToolPath string) (string, er
 		if err != nil {
 			return "", err
 		}
+	} else if isPackageFile(downloadedToolPath) {
+		var err error
+		extractedToolPath, err = extractFromPackage(tool, downloadedToolPath, dir)
+		if err != nil {
+			return "", err
+		}
 	} else {
 		extractedToolPath = downloadedToolPath
 	}
@@ -188,8 +247,8 @@ func extractFromArchive(tool api.Tool, archivePath, dir string) (string, error)
 func isMatchingBinary(tool api.Tool, filePath string) bool {
 	base := filepath.Base(filePath)
 	return base == tool.Name ||
-		base == tool.Name+"-"+runtime.GOOS+"-"+runtime.GOARCH ||
-		base == tool.Name+"_"+runtime.GOOS+"_"+runtime.GOARCH
+		base == tool.Name+"-"+tool.OS+"-"+tool.Arch ||
+		base == tool.Name+"_"+tool.OS+"_"+tool.Arch
 }
 
 // extractBinary extracts a binary file from an archive to a directory.
@@ -200,51 +259,291 @@ func extractBinary(archiveFS fs.FS, srcPath, destDir string) (string, error) {
 	}
 	defer src.Close()
 
-	destPath := filepath.Join(destDir, filepath.Base(srcPath))
-	dest, err := os.Create(destPath)
+	return writeBinary(src, srcPath, destDir)
+}
+
+// getLatestVersion returns the latest version of a tool in the release channel
+// that is configured for it. Tools without a configured channel, or without
+// versions in that channel, use the stable channel.
+func getLatestVersion(
+	toolctlAPI api.ToolctlAPI, tool api.Tool,
+) (version *api.Version, err error) {
+	channel := viper.GetStringMapString("Channels")[strings.ToLower(tool.Name)]
+	if channel != "" {
+		version, err = api.GetLatestVersionInChannel(
+			toolctlAPI, tool, strings.ToLower(channel),
+		)
+		if !errors.Is(err, api.NotFoundError{}) {
+			return
+		}
+	}
+	return api.GetLatestVersion(toolctlAPI, tool)
+}
+
+// getReleaseDate returns the release date of a version of a tool, or the zero
+// time if the API doesn't know it.
+func getReleaseDate(
+	toolctlAPI api.ToolctlAPI, tool api.Tool, version *api.Version,
+) (releaseDate time.Time, err error) {
+	tool.Version = version.String()
+	toolPlatformVersionMeta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
 	if err != nil {
-		return "", err
+		return
 	}
-	defer dest.Close()
+	releaseDate = toolPlatformVersionMeta.ReleaseDate
+	return
+}
 
-	if _, err := io.Copy(dest, src); err != nil {
-		return "", err
+// checkReleaseAge checks if a version of a tool was released long enough ago
+// to be installed, according to MinReleaseAge. If it wasn't, the reason is
+// returned. Versions without a known release date are allowed.
+func checkReleaseAge(
+	toolctlAPI api.ToolctlAPI, tool api.Tool, version *api.Version,
+) (tooRecent string, err error) {
+	minReleaseAge, err := parseAge(viper.GetString("MinReleaseAge"))
+	if err != nil {
+		err = fmt.Errorf("invalid MinReleaseAge: %w", err)
+		return
+	}
+	if minReleaseAge == 0 {
+		return
+	}
+
+	releaseDate, err := getReleaseDate(toolctlAPI, tool, version)
+	if err != nil || releaseDate.IsZero() {
+		return
 	}
 
-	return destPath, nil
+	age := time.Since(releaseDate)
+	if age < minReleaseAge {
+		tooRecent = fmt.Sprintf(
+			"v%s was released %s ago, but the minimum release age is %s",
+			version, formatAge(age), formatAge(minReleaseAge),
+		)
+	}
+	return
+}
+
+// getOldEnoughVersion returns the newest version of a tool, up to the latest
+// version, that was released at least MinReleaseAge ago. Older versions are
+// only considered if they are stable or in the same release channel, and
+// haven't been yanked. If the latest version is too recent, the reason is
+// returned, and version is nil if no older version qualifies either.
+func getOldEnoughVersion(
+	toolctlAPI api.ToolctlAPI, tool api.Tool, latestVersion *api.Version,
+) (version *api.Version, tooRecent string, err error) {
+	tooRecent, err = checkReleaseAge(toolctlAPI, tool, latestVersion)
+	if err != nil {
+		return
+	}
+	if tooRecent == "" {
+		version = latestVersion
+		return
+	}
+
+	versions, err := api.GetVersions(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+	channel := api.VersionChannel(latestVersion)
+	for _, candidate := range versions {
+		candidateChannel := api.VersionChannel(candidate)
+		if !candidate.LessThan(latestVersion) ||
+			(candidateChannel != api.StableChannel && candidateChannel != channel) {
+			continue
+		}
+
+		tool.Version = candidate.String()
+		var toolPlatformVersionMeta api.ToolPlatformVersionMeta
+		toolPlatformVersionMeta, err = api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+		if err != nil {
+			return
+		}
+		if toolPlatformVersionMeta.Yanked {
+			continue
+		}
+
+		var candidateTooRecent string
+		candidateTooRecent, err = checkReleaseAge(toolctlAPI, tool, candidate)
+		if err != nil {
+			return
+		}
+		if candidateTooRecent == "" {
+			version = candidate
+			return
+		}
+	}
+	return
+}
+
+// parseAge parses an age like "3d" or "12h". In addition to the units of
+// time.ParseDuration, "d" is supported for days.
+func parseAge(s string) (age time.Duration, err error) {
+	if s == "" {
+		return
+	}
+	if days, found := strings.CutSuffix(s, "d"); found {
+		var n int
+		n, err = strconv.Atoi(days)
+		if err != nil {
+			err = fmt.Errorf("invalid number of days: %s", s)
+			return
+		}
+		age = time.Duration(n) * 24 * time.Hour
+		return
+	}
+	return time.ParseDuration(s)
+}
+
+// formatAge formats an age in the largest unit that fits, e.g. "3 days".
+func formatAge(age time.Duration) string {
+	pluralize := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	switch {
+	case age < time.Minute:
+		return "less than a minute"
+	case age < time.Hour:
+		return pluralize(int(age/time.Minute), "minute")
+	case age < 48*time.Hour:
+		return pluralize(int(age/time.Hour), "hour")
+	default:
+		return pluralize(int(age/(24*time.Hour)), "day")
+	}
+}
+
+// defaultVersionTimeout is how long a tool binary may take to print its
+// version, unless VersionTimeout is configured.
+const defaultVersionTimeout = 10 * time.Second
+
+// versionTimeoutError is returned when a tool binary doesn't print its version
+// in time.
+type versionTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e versionTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"⏱️ Timed out: could not determine installed version within %s", e.timeout,
+	)
+}
+
+// isVersionUndetermined checks if an error of getToolBinaryVersion means that
+// the tool ran, but didn't report a version, as opposed to toolctl failing.
+func isVersionUndetermined(err error) bool {
+	var exitError *exec.ExitError
+	var timeoutError versionTimeoutError
+	return errors.As(err, &exitError) || errors.As(err, &timeoutError)
 }
 
 // getToolBinaryVersion retrieves the version of a tool binary by executing it.
+// The version is taken from the "version" group of the version regex, or from
+// its first group if there is no such group, or from the whole match. Without
+// a version regex, versions of the tool's version scheme are matched.
 func getToolBinaryVersion(
-	toolPath string, versionArgs []string,
-) (version *semver.Version, err error) {
-	versionRegex := `(\d+\.\d+\.\d+)`
-
+	toolPath string, toolMeta api.ToolMeta,
+) (version *api.Version, err error) {
+	scheme, err := api.GetVersionScheme(toolMeta)
+	if err != nil {
+		return
+	}
+	versionRegex := toolMeta.VersionRegex
+	if versionRegex == "" {
+		versionRegex = scheme.Regex()
+	}
 	r, err := regexp.Compile(versionRegex)
+	if err != nil {
+		err = fmt.Errorf("invalid versionRegex: %w", err)
+		return
+	}
+
+	switch toolMeta.VersionStream {
+	case "", "stdout", "stderr", "both":
+	default:
+		err = fmt.Errorf(
+			"invalid versionStream %s, must be stdout, stderr or both",
+			toolMeta.VersionStream,
+		)
+		return
+	}
+
+	timeout := viper.GetDuration("VersionTimeout")
+	if timeout <= 0 {
+		timeout = defaultVersionTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Give the tool its own HOME and working directory, so that it can't pick
+	// up or modify the user's config files or the current directory
+	sandboxDir, err := os.MkdirTemp("", "toolctl-version-*")
 	if err != nil {
 		return
 	}
+	defer os.RemoveAll(sandboxDir)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, toolPath, toolMeta.VersionArgs...)
+	cmd.Dir = sandboxDir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + sandboxDir,
+		"TMPDIR=" + sandboxDir,
+		"LANG=C",
+	}
+	// Stdin is left nil, so it reads from the null device and tools that
+	// prompt for input get EOF instead of waiting
+	cmd.WaitDelay = time.Second
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if toolMeta.VersionStream == "both" {
+		// Use a single buffer for both streams to keep their output in order
+		cmd.Stderr = &stdout
+	}
 
-	out, err := exec.Command(toolPath, versionArgs...).Output()
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = versionTimeoutError{timeout: timeout}
+		return
+	}
 	if err != nil {
 		var exitError *exec.ExitError
 		if errors.As(err, &exitError) {
+			// With both streams, stderr was written to the stdout buffer
+			errorOutput := stderr.String()
+			if toolMeta.VersionStream == "both" {
+				errorOutput = stdout.String()
+			}
 			err = fmt.Errorf("❌ Could not determine installed version: %s (%w)",
-				strings.TrimSpace(string(exitError.Stderr)), err,
+				strings.TrimSpace(errorOutput), err,
 			)
 		}
 		return
 	}
 
-	rawVersion := strings.TrimSpace(string(out))
+	out := stdout.String()
+	if toolMeta.VersionStream == "stderr" {
+		out = stderr.String()
+	}
+	rawVersion := strings.TrimSpace(out)
 
 	match := r.FindStringSubmatch(rawVersion)
-	if len(match) < 2 {
+	if match == nil {
 		err = fmt.Errorf("could not find version in output: %s", rawVersion)
 		return
 	}
+	rawVersion = match[0]
+	if i := r.SubexpIndex("version"); i > 0 {
+		rawVersion = match[i]
+	} else if len(match) > 1 {
+		rawVersion = match[1]
+	}
 
-	version, err = semver.NewVersion(match[1])
+	version, err = scheme.Parse(rawVersion)
 	return
 }
 
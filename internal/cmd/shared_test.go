@@ -4,14 +4,24 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -22,8 +32,60 @@ import (
 	"github.com/spf13/viper"
 	"github.com/toolctl/toolctl/internal/api"
 	"github.com/toolctl/toolctl/internal/cmd"
+	"github.com/toolctl/toolctl/internal/oci/ocitest"
+	"github.com/toolctl/toolctl/internal/verify"
 )
 
+// testSigningKey is used to sign the downloads of tools with signed set, and
+// the provenance of tools with provenanceBuilderID set.
+var testSigningKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+// testBuilderID is the builder that the tool metadata trusts for tools with
+// provenanceBuilderID set.
+const testBuilderID = "https://github.com/slsa-framework/slsa-github-generator" +
+	"/.github/workflows/generator_generic_slsa3.yml"
+
+// testOCIRegistry hosts the downloads of tools with ociBlob set.
+var testOCIRegistry *ocitest.Registry
+
+// testToolBinaryPath is the path of the compiled fake tool binary, see
+// testdata/testtool.
+var testToolBinaryPath string
+
+// TestMain builds the fake tool binary before running the tests.
+func TestMain(m *testing.M) {
+	tempDir, err := os.MkdirTemp("", "toolctl-test-tool-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	testToolBinaryPath = filepath.Join(tempDir, "testtool")
+	out, err := exec.Command(
+		"go", "build", "-ldflags", "-s -w", "-o", testToolBinaryPath, "./testdata/testtool",
+	).CombinedOutput()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "building the test tool failed: %v\n%s", err, out)
+		os.RemoveAll(tempDir)
+		os.Exit(1)
+	}
+
+	// Tool binaries must not see the environment of toolctl
+	err = os.Setenv("TOOLCTL_TEST_SECRET", "secret")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.RemoveAll(tempDir)
+		os.Exit(1)
+	}
+
+	testOCIRegistry = ocitest.NewRegistry()
+	code := m.Run()
+	testOCIRegistry.Close()
+
+	os.RemoveAll(tempDir)
+	os.Exit(code)
+}
+
 // TestArgsToTools tests the ArgsToTools function, ensuring correct parsing of tool names and versions.
 func TestArgsToTools(t *testing.T) {
 	type args struct {
@@ -91,6 +153,12 @@ func TestArgsToTools(t *testing.T) {
 
 const localAPIBasePath = "/toolctl/tools/v0"
 
+type testReceipt struct {
+	name           string
+	version        string
+	binaryContents string
+}
+
 type preinstalledTool struct {
 	name         string
 	fileContents string
@@ -104,7 +172,31 @@ type supportedTool struct {
 	downloadURLTemplatePath       string
 	ignoredVersions               []string
 	onlyOnDownloadServer          bool
+	shellScript                   bool
 	tarGz                         bool
+	deb                           bool
+	rpm                           bool
+	rpmHardlink                   bool
+	rpmUncompressed               bool
+	signed                        bool
+	signatureInvalid              bool
+	publicKeyName                 string
+	checksumFile                  bool
+	checksumFileMismatch          bool
+	digests                       []string
+	digestMismatch                bool
+	provenanceBuilderID           string
+	versionRegex                  string
+	versionStream                 string
+	versionScheme                 string
+	releaseDate                   string
+	yankedReason                  string
+	unreachableURL                bool
+	urlPrefix                     string
+	ociBlob                       bool
+	channels                      map[string]string
+	prereleaseChannels            []string
+	apiBinaryContents             string
 	tarGzSubdir                   string
 	tarGzBinaryName               string
 }
@@ -116,6 +208,7 @@ type test struct {
 	installDirNotWritable       bool
 	installDirNotPreinstallDir  bool
 	supportedTools              []supportedTool
+	apiIndex                    bool
 	preinstalledTools           []preinstalledTool
 	preinstalledToolIsSymlinked bool
 	cliArgs                     []string
@@ -123,6 +216,8 @@ type test struct {
 	wantOut                     string
 	wantOutRegex                string
 	wantFiles                   []APIFile
+	config                      map[string]any
+	receipts                    []testReceipt
 }
 
 // setupPreinstallTempDir creates a temporary directory for preinstalled tools and sets up symlinks if needed.
@@ -183,10 +278,11 @@ func setupRemoteAPI(supportedTools []supportedTool) (
 
 	// Create the API content for all supported tools
 	var apiFiles []APIFile
-	supportedToolNames := make([]string, len(supportedTools))
+	supportedToolNames := make([]string, 0, len(supportedTools))
 	for _, supportedTool := range supportedTools {
 		var sha256 string
-		sha256, err = supportedToolToDownloadFile(downloadServerFS, supportedTool)
+		var digests map[string]string
+		sha256, digests, err = supportedToolToDownloadFile(downloadServerFS, supportedTool)
 		if err != nil {
 			return
 		}
@@ -194,7 +290,7 @@ func setupRemoteAPI(supportedTools []supportedTool) (
 		if !supportedTool.onlyOnDownloadServer {
 			apiFiles = append(
 				apiFiles,
-				supportedToolToAPIContents(supportedTool, downloadServer.URL, sha256)...,
+				supportedToolToAPIContents(supportedTool, downloadServer.URL, sha256, digests)...,
 			)
 		}
 
@@ -231,6 +327,21 @@ func setupRemoteAPI(supportedTools []supportedTool) (
 	return
 }
 
+// publishIndex builds the index of the API files, like api sync does, so that
+// the versions of the tools can be listed.
+func publishIndex(localAPIFS afero.Fs) (err error) {
+	localAPI, err := api.NewLocalAPI(localAPIFS, localAPIBasePath)
+	if err != nil {
+		return
+	}
+	index, err := api.BuildIndex(localAPI)
+	if err != nil {
+		return
+	}
+	err = api.SaveIndex(localAPI, index)
+	return
+}
+
 // setupLocalAPI sets up a mock local API filesystem and optionally creates metadata.
 func setupLocalAPI(supportedTools []supportedTool, createTopLevelMeta bool) (
 	localAPIFS afero.Fs, downloadServer *httptest.Server, err error,
@@ -245,10 +356,11 @@ func setupLocalAPI(supportedTools []supportedTool, createTopLevelMeta bool) (
 
 	// Create the API content for all supported tools
 	var apiFiles []APIFile
-	supportedToolNames := make([]string, len(supportedTools))
+	supportedToolNames := make([]string, 0, len(supportedTools))
 	for _, supportedTool := range supportedTools {
 		var sha256 string
-		sha256, err = supportedToolToDownloadFile(downloadServerFS, supportedTool)
+		var digests map[string]string
+		sha256, digests, err = supportedToolToDownloadFile(downloadServerFS, supportedTool)
 		if err != nil {
 			return
 		}
@@ -256,7 +368,7 @@ func setupLocalAPI(supportedTools []supportedTool, createTopLevelMeta bool) (
 		if !supportedTool.onlyOnDownloadServer {
 			apiFiles = append(
 				apiFiles,
-				supportedToolToAPIContents(supportedTool, downloadServer.URL, sha256)...,
+				supportedToolToAPIContents(supportedTool, downloadServer.URL, sha256, digests)...,
 			)
 		}
 
@@ -350,12 +462,13 @@ func checkWantOut(t *testing.T, tt test, buf *bytes.Buffer) {
 	}
 }
 
-// supportedToolToDownloadFile creates a tar.gz file for a tool and calculates its SHA256 checksum.
+// supportedToolToDownloadFile creates a download file for a tool and calculates its SHA256 checksum,
+// as well as the additional digests requested by the tool.
 func supportedToolToDownloadFile(
 	downloadServerFS afero.Fs, supportedTool supportedTool,
-) (sha256 string, err error) {
-	if !supportedTool.tarGz {
-		err = fmt.Errorf("Only tar.gz supported for now")
+) (sha256 string, digests map[string]string, err error) {
+	if !supportedTool.tarGz && !supportedTool.deb && !supportedTool.rpm {
+		err = fmt.Errorf("Only tar.gz, deb and rpm supported for now")
 		return
 	}
 
@@ -368,24 +481,162 @@ func supportedToolToDownloadFile(
 		return
 	}
 
-	tarFilePath, err := createTarFile(downloadServerFS, filePath, supportedTool)
+	var downloadFilePath string
+	switch {
+	case supportedTool.deb:
+		downloadFilePath, err = createDebFile(downloadServerFS, filePath)
+	case supportedTool.rpm:
+		downloadFilePath, err = createRPMFile(downloadServerFS, filePath, supportedTool)
+	default:
+		var tarFilePath string
+		tarFilePath, err = createTarFile(downloadServerFS, filePath, supportedTool)
+		if err != nil {
+			return
+		}
+		downloadFilePath, err = createTarGzFile(tarFilePath, downloadServerFS)
+	}
 	if err != nil {
 		return
 	}
 
-	tarGzFilePath, err := createTarGzFile(tarFilePath, downloadServerFS)
+	if supportedTool.signed {
+		err = createSignatureFile(downloadServerFS, downloadFilePath, supportedTool)
+		if err != nil {
+			return
+		}
+	}
+
+	sha256, err = calculateSHA256(downloadServerFS, downloadFilePath)
 	if err != nil {
 		return
 	}
 
-	sha256, err = calculateSHA256(downloadServerFS, tarGzFilePath)
+	if supportedTool.ociBlob {
+		var contents []byte
+		contents, err = afero.ReadFile(downloadServerFS, downloadFilePath)
+		if err != nil {
+			return
+		}
+		testOCIRegistry.PushBlob(contents)
+	}
+
+	if supportedTool.provenanceBuilderID != "" {
+		err = createProvenanceFile(downloadServerFS, downloadFilePath, sha256, supportedTool)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(supportedTool.digests) > 0 {
+		var downloadFile afero.File
+		downloadFile, err = downloadServerFS.Open(downloadFilePath)
+		if err != nil {
+			return
+		}
+		defer downloadFile.Close()
+
+		digests, err = verify.Digests(downloadFile, supportedTool.digests)
+		if err != nil {
+			return
+		}
+		if supportedTool.digestMismatch {
+			digests[supportedTool.digests[0]] = strings.Repeat("0", len(digests[supportedTool.digests[0]]))
+		}
+	}
+
+	if supportedTool.checksumFile {
+		upstreamSHA256 := sha256
+		if supportedTool.checksumFileMismatch {
+			upstreamSHA256 = strings.Repeat("0", 64)
+		}
+		err = afero.WriteFile(
+			downloadServerFS,
+			path.Join(path.Dir(downloadFilePath), "checksums.txt"),
+			[]byte(upstreamSHA256+"  "+path.Base(downloadFilePath)+"\n"),
+			0644,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// createProvenanceFile creates a SLSA provenance attestation for a download
+// file, signed with testSigningKey, like the SLSA GitHub generator does, and
+// stores it next to it.
+func createProvenanceFile(
+	downloadServerFS afero.Fs, downloadFilePath string, downloadSHA256 string,
+	supportedTool supportedTool,
+) (err error) {
+	statement := fmt.Sprintf(`{
+  "_type": "https://in-toto.io/Statement/v0.1",
+  "predicateType": "https://slsa.dev/provenance/v0.2",
+  "subject": [{"name": "%s", "digest": {"sha256": "%s"}}],
+  "predicate": {"builder": {"id": "%s"}}
+}`,
+		path.Base(downloadFilePath), downloadSHA256, supportedTool.provenanceBuilderID,
+	)
+
+	const payloadType = "application/vnd.in-toto+json"
+	pae := fmt.Sprintf(
+		"DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(statement), statement,
+	)
+	digest := sha256.Sum256([]byte(pae))
+	signature, err := ecdsa.SignASN1(rand.Reader, testSigningKey, digest[:])
+	if err != nil {
+		return
+	}
+
+	err = afero.WriteFile(
+		downloadServerFS, downloadFilePath+".intoto.jsonl",
+		[]byte(fmt.Sprintf(
+			`{"payloadType": "%s", "payload": "%s", "signatures": [{"keyid": "", "sig": "%s"}]}`+"\n",
+			payloadType,
+			base64.StdEncoding.EncodeToString([]byte(statement)),
+			base64.StdEncoding.EncodeToString(signature),
+		)),
+		0644,
+	)
+	return
+}
+
+// createSignatureFile signs a download file with testSigningKey, like
+// `cosign sign-blob` does, and stores the signature next to it.
+func createSignatureFile(
+	downloadServerFS afero.Fs, downloadFilePath string, supportedTool supportedTool,
+) (err error) {
+	contents, err := afero.ReadFile(downloadServerFS, downloadFilePath)
+	if err != nil {
+		return
+	}
+	if supportedTool.signatureInvalid {
+		contents = append(contents, []byte("tampered")...)
+	}
+
+	digest := sha256.Sum256(contents)
+	signature, err := ecdsa.SignASN1(rand.Reader, testSigningKey, digest[:])
 	if err != nil {
 		return
 	}
 
+	err = afero.WriteFile(
+		downloadServerFS, downloadFilePath+".sig",
+		[]byte(base64.StdEncoding.EncodeToString(signature)), 0644,
+	)
 	return
 }
 
+// testSigningPublicKey returns the public key of testSigningKey in PEM format.
+func testSigningPublicKey() string {
+	der, err := x509.MarshalPKIXPublicKey(&testSigningKey.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
 // createTarGzFile compresses a tar file into a tar.gz file using gzip.
 func createTarGzFile(
 	tarFilePath string, downloadServerFS afero.Fs,
@@ -416,6 +667,154 @@ func createTarGzFile(
 	return
 }
 
+// createDebFile packages a binary file as a .deb file for testing purposes.
+// The binary is placed in usr/bin of the compressed data archive.
+func createDebFile(
+	downloadServerFS afero.Fs, filePath string,
+) (debFilePath string, err error) {
+	binary, err := afero.ReadFile(downloadServerFS, filePath)
+	if err != nil {
+		return
+	}
+
+	dataTarGz, err := tarGzBytes("./usr/bin/"+filepath.Base(filePath), binary)
+	if err != nil {
+		return
+	}
+	controlTarGz, err := tarGzBytes("./control", []byte("Package: toolctl-test-tool\n"))
+	if err != nil {
+		return
+	}
+
+	deb := bytes.NewBufferString("!<arch>\n")
+	for _, member := range []struct {
+		name     string
+		contents []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", controlTarGz},
+		{"data.tar.gz", dataTarGz},
+	} {
+		fmt.Fprintf(
+			deb, "%-16s%-12d%-6d%-6d%-8s%-10d`\n",
+			member.name, 0, 0, 0, "100644", len(member.contents),
+		)
+		deb.Write(member.contents)
+		if len(member.contents)%2 == 1 {
+			deb.WriteByte('\n')
+		}
+	}
+
+	debFilePath = filePath + ".deb"
+	err = afero.WriteFile(downloadServerFS, debFilePath, deb.Bytes(), 0644)
+	return
+}
+
+// createRPMFile packages a binary file as an .rpm file for testing purposes.
+// The headers are empty, the payload is a cpio archive, gzipped unless the
+// tool is uncompressed. If the tool is hardlinked, the binary is an entry
+// without data, which is stored with a hardlink after it instead.
+func createRPMFile(
+	downloadServerFS afero.Fs, filePath string, supportedTool supportedTool,
+) (rpmFilePath string, err error) {
+	binary, err := afero.ReadFile(downloadServerFS, filePath)
+	if err != nil {
+		return
+	}
+
+	rpm := &bytes.Buffer{}
+
+	// Lead
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	rpm.Write(lead)
+
+	// Empty signature header and main header
+	for range 2 {
+		rpm.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	}
+
+	// Payload
+	type cpioEntry struct {
+		name     string
+		ino      int
+		mode     int
+		nlink    int
+		contents []byte
+	}
+	entries := []cpioEntry{
+		{"./usr/share/doc/README", 1, 0100644, 1, []byte("toolctl")},
+		{"./usr/bin/" + filepath.Base(filePath), 2, 0100755, 1, binary},
+	}
+	if supportedTool.rpmHardlink {
+		entries = []cpioEntry{
+			entries[0],
+			{"./usr/bin/" + filepath.Base(filePath), 2, 0100755, 2, nil},
+			{"./usr/libexec/" + filepath.Base(filePath), 2, 0100755, 2, binary},
+		}
+	}
+	entries = append(entries, cpioEntry{"TRAILER!!!", 0, 0, 1, nil})
+
+	cpio := &bytes.Buffer{}
+	for _, entry := range entries {
+		fmt.Fprintf(
+			cpio, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			entry.ino, entry.mode, 0, 0, entry.nlink, 0, len(entry.contents), 0, 0, 0, 0,
+			len(entry.name)+1, 0,
+		)
+		cpio.WriteString(entry.name + "\x00")
+		cpio.Write(make([]byte, (4-(110+len(entry.name)+1)%4)%4))
+		cpio.Write(entry.contents)
+		cpio.Write(make([]byte, (4-len(entry.contents)%4)%4))
+	}
+
+	if supportedTool.rpmUncompressed {
+		rpm.Write(cpio.Bytes())
+	} else {
+		gzipWriter := gzip.NewWriter(rpm)
+		_, err = gzipWriter.Write(cpio.Bytes())
+		if err != nil {
+			return
+		}
+		err = gzipWriter.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	rpmFilePath = filePath + ".rpm"
+	err = afero.WriteFile(downloadServerFS, rpmFilePath, rpm.Bytes(), 0644)
+	return
+}
+
+// tarGzBytes returns a gzipped tar archive containing a single file.
+func tarGzBytes(name string, contents []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name:     name,
+		Size:     int64(len(contents)),
+		Mode:     0755,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tarWriter.Write(contents); err != nil {
+		return nil, err
+	}
+	if err = tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 // createTarFile creates a tar file from a binary file for testing purposes.
 func createTarFile(
 	downloadServerFS afero.Fs, filePath string, supportedTool supportedTool,
@@ -460,6 +859,7 @@ func createTarFile(
 }
 
 // createBinaryFile generates a mock binary file for a tool in the test environment.
+// Unless shellScript is set, it is a real executable for the current platform.
 func createBinaryFile(
 	downloadServerFS afero.Fs, supportedTool supportedTool,
 ) (filePath string, err error) {
@@ -471,24 +871,118 @@ func createBinaryFile(
 		runtime.GOOS, runtime.GOARCH, supportedTool.version, supportedTool.name,
 	)
 
-	err = afero.WriteFile(
-		downloadServerFS,
-		filePath,
-		[]byte(`#!/bin/sh
+	if supportedTool.shellScript {
+		err = afero.WriteFile(
+			downloadServerFS,
+			filePath,
+			[]byte(`#!/bin/sh
 echo v`+supportedTool.binaryVersion+`
 `),
-		0644,
+			0644,
+		)
+		return
+	}
+
+	// Append the version to the fake tool binary, which prints it when called
+	contents, err := os.ReadFile(testToolBinaryPath)
+	if err != nil {
+		return
+	}
+	contents = append(
+		contents,
+		[]byte("\nTOOLCTL_TEST_TOOL_VERSION="+supportedTool.binaryVersion+"\n")...,
 	)
 
+	err = afero.WriteFile(downloadServerFS, filePath, contents, 0644)
+
 	return
 }
 
 // supportedToolToAPIContents generates API metadata files for a tool, including download URLs and versions.
 func supportedToolToAPIContents(
 	supportedTool supportedTool, downloadServerURL string, sha256 string,
+	digests map[string]string,
 ) (apiFiles []APIFile) {
+	extension := ".tar.gz"
+	if supportedTool.deb {
+		extension = ".deb"
+	} else if supportedTool.rpm {
+		extension = ".rpm"
+	}
+
 	if supportedTool.downloadURLTemplatePath == "" {
-		supportedTool.downloadURLTemplatePath = "/{{.OS}}/{{.Arch}}/{{.Version}}/{{.Name}}.tar.gz"
+		supportedTool.downloadURLTemplatePath = "/{{.OS}}/{{.Arch}}/{{.Version}}/{{.Name}}" + extension
+	}
+
+	var extraToolMeta, extraVersionMeta string
+	if supportedTool.signed || supportedTool.provenanceBuilderID != "" {
+		if supportedTool.publicKeyName == "" {
+			supportedTool.publicKeyName = "test-key"
+		}
+		extraToolMeta += "publicKeys:\n  " + supportedTool.publicKeyName + ": |\n    " +
+			strings.ReplaceAll(strings.TrimSpace(testSigningPublicKey()), "\n", "\n    ") + "\n"
+	}
+	if supportedTool.signed {
+		extraVersionMeta += fmt.Sprintf(`signature:
+  url: %s/%s/%s/%s/%s%s.sig
+  key: test-key
+  type: cosign
+`,
+			downloadServerURL, runtime.GOOS, runtime.GOARCH, supportedTool.version,
+			supportedTool.name, extension,
+		)
+	}
+
+	if supportedTool.provenanceBuilderID != "" {
+		extraToolMeta += "provenance:\n  builderIDs:\n  - " + testBuilderID + "\n"
+		extraVersionMeta += fmt.Sprintf(`provenance:
+  url: %s/%s/%s/%s/%s%s.intoto.jsonl
+  key: test-key
+`,
+			downloadServerURL, runtime.GOOS, runtime.GOARCH, supportedTool.version,
+			supportedTool.name, extension,
+		)
+	}
+
+	if supportedTool.apiBinaryContents != "" {
+		extraVersionMeta += fmt.Sprintf(
+			"binarySHA256: %x\n", sha256Sum([]byte(supportedTool.apiBinaryContents)),
+		)
+	}
+
+	if supportedTool.yankedReason != "" {
+		extraVersionMeta += "yanked: true\nyankedReason: " + supportedTool.yankedReason + "\n"
+	}
+
+	if supportedTool.releaseDate != "" {
+		extraVersionMeta += "releaseDate: " + supportedTool.releaseDate + "\n"
+	}
+
+	if len(digests) > 0 {
+		extraVersionMeta += "digests:\n"
+		for _, algorithm := range supportedTool.digests {
+			extraVersionMeta += fmt.Sprintf("  %s: %s\n", algorithm, digests[algorithm])
+		}
+	}
+
+	if supportedTool.versionRegex != "" {
+		extraToolMeta += "versionRegex: '" + supportedTool.versionRegex + "'\n"
+	}
+	if supportedTool.versionStream != "" {
+		extraToolMeta += "versionStream: " + supportedTool.versionStream + "\n"
+	}
+	if supportedTool.versionScheme != "" {
+		extraToolMeta += "versionScheme: " + supportedTool.versionScheme + "\n"
+	}
+
+	if len(supportedTool.prereleaseChannels) > 0 {
+		extraToolMeta += "prereleaseChannels: [" +
+			strings.Join(supportedTool.prereleaseChannels, ", ") + "]\n"
+	}
+
+	if supportedTool.checksumFile {
+		extraToolMeta += "checksumURLTemplate: " + downloadServerURL +
+			"/{{.OS}}/{{.Arch}}/{{.Version}}/checksums.txt\n"
 	}
 
 	apiFiles = []APIFile{
@@ -499,7 +993,7 @@ downloadURLTemplate: ` + downloadServerURL + supportedTool.downloadURLTemplatePa
 ignoredVersions: ['` + strings.Join(supportedTool.ignoredVersions[:], "', '") + `']
 homepage: https://toolctl.io/
 versionArgs: [version, --short]
-`,
+` + extraToolMeta,
 		},
 	}
 
@@ -513,20 +1007,15 @@ versionArgs: [version, --short]
 				Contents: fmt.Sprintf(`version:
   earliest: %s
   latest: %s
-`, supportedTool.version, supportedTool.version),
+`, supportedTool.version, supportedTool.version) + channelsMeta(supportedTool.channels),
 			},
 			APIFile{
 				Path: path.Join(
 					localAPIBasePath, supportedTool.name, runtime.GOOS+"-"+runtime.GOARCH,
 					supportedTool.version+".yaml",
 				),
-				Contents: fmt.Sprintf(
-					`url: %s/%s/%s/%s/%s.tar.gz
-sha256: %s
-`,
-					downloadServerURL, runtime.GOOS, runtime.GOARCH, supportedTool.version,
-					supportedTool.name, sha256,
-				),
+				Contents: versionURLMeta(supportedTool, downloadServerURL, extension, sha256) +
+					"sha256: " + sha256 + "\n" + extraVersionMeta,
 			},
 		)
 	}
@@ -534,6 +1023,40 @@ sha256: %s
 	return
 }
 
+// versionURLMeta returns the URL and mirrors of a tool version meta file. If
+// the URL is unreachable, the download is only available from a mirror. OCI
+// blobs are referred to by their digest.
+func versionURLMeta(
+	supportedTool supportedTool, downloadServerURL string, extension string, sha256 string,
+) (meta string) {
+	if supportedTool.ociBlob {
+		return "url: " + testOCIRegistry.Reference("tools") + "@sha256:" + sha256 +
+			"#" + supportedTool.name + extension + "\n"
+	}
+	url := supportedTool.urlPrefix + fmt.Sprintf("%s/%s/%s/%s/%s%s",
+		downloadServerURL, runtime.GOOS, runtime.GOARCH, supportedTool.version,
+		supportedTool.name, extension,
+	)
+	if !supportedTool.unreachableURL {
+		return "url: " + url + "\n"
+	}
+	unreachableURL := supportedTool.urlPrefix + downloadServerURL + "/unreachable/" +
+		supportedTool.name + extension
+	return "url: " + unreachableURL + "\nmirrors:\n  - " + url + "\n"
+}
+
+// channelsMeta returns the channels of a tool platform meta file.
+func channelsMeta(channels map[string]string) (meta string) {
+	if len(channels) == 0 {
+		return
+	}
+	meta = "  channels:\n"
+	for _, channel := range slices.Sorted(maps.Keys(channels)) {
+		meta += fmt.Sprintf("    %s: %s\n", channel, channels[channel])
+	}
+	return
+}
+
 // runInstallUpgradeTests executes tests for install or upgrade commands, verifying results.
 func runInstallUpgradeTests(
 	t *testing.T, tests []test, installOrUpgrade string,
@@ -547,6 +1070,12 @@ func runInstallUpgradeTests(
 		if err != nil {
 			t.Fatal(err)
 		}
+		if tt.apiIndex {
+			err = publishIndex(toolctlAPI.LocalAPIFS())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
 
 		installTempDir, err := os.MkdirTemp("", "toolctl-test-install-*")
 		if err != nil {
@@ -571,6 +1100,8 @@ func runInstallUpgradeTests(
 			}
 		}
 
+		receiptsTempDir := setupReceiptsTempDir(t, tt, installTempDir)
+
 		t.Run(tt.name, func(t *testing.T) {
 			buf := new(bytes.Buffer)
 
@@ -583,6 +1114,11 @@ func runInstallUpgradeTests(
 				tmpInstallDirSuffix = "-nonexistent"
 			}
 			viper.Set("InstallDir", installTempDir+tmpInstallDirSuffix)
+			viper.Set("ReceiptsDir", receiptsTempDir)
+			for key, value := range tt.config {
+				viper.Set(key, value)
+				defer viper.Set(key, nil)
+			}
 
 			// Redirect Cobra output to a buffer
 			command.SetOut(buf)
@@ -611,6 +1147,11 @@ func runInstallUpgradeTests(
 			t.Fatal(err)
 		}
 
+		err = os.RemoveAll(receiptsTempDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
 		apiServer.Close()
 		downloadServer.Close()
 	}
@@ -630,3 +1171,38 @@ func setupInstallTempDir(t *testing.T, tt test) (installTempDir string) {
 	}
 	return
 }
+
+// setupReceiptsTempDir creates a temporary directory for receipts and writes the receipts of the test.
+func setupReceiptsTempDir(
+	t *testing.T, tt test, installTempDir string,
+) (receiptsTempDir string) {
+	receiptsTempDir, err := os.MkdirTemp("", "toolctl-test-receipts-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range tt.receipts {
+		err = os.WriteFile(
+			filepath.Join(receiptsTempDir, r.name+".yaml"),
+			[]byte(fmt.Sprintf(`name: %s
+version: %s
+binarySHA256: %x
+installPath: %s
+`,
+				r.name, r.version, sha256Sum([]byte(r.binaryContents)),
+				filepath.Join(installTempDir, r.name),
+			)),
+			0644,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return
+}
+
+// sha256Sum returns the SHA256 digest of the given contents.
+func sha256Sum(contents []byte) [sha256.Size]byte {
+	return sha256.Sum256(contents)
+}
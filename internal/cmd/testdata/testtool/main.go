@@ -0,0 +1,35 @@
+// Package main is a fake tool binary used by the cmd tests.
+//
+// The tests append a version marker to a copy of the compiled binary, so a
+// single build can pose as any version of any tool.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+const versionMarker = "\nTOOLCTL_TEST_TOOL_VERSION="
+
+func main() {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	contents, err := os.ReadFile(executable)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	i := bytes.LastIndex(contents, []byte(versionMarker))
+	if i == -1 {
+		fmt.Fprintln(os.Stderr, "no version marker found")
+		os.Exit(1)
+	}
+
+	fmt.Printf("v%s\n", bytes.TrimSpace(contents[i+len(versionMarker):]))
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/toolctl/toolctl/internal/advisory"
 	"github.com/toolctl/toolctl/internal/api"
 )
 
@@ -38,7 +39,7 @@ func newRunUpgrade(
 	toolctlWriter io.Writer, localAPIFS afero.Fs,
 ) func(cmd *cobra.Command, args []string) (err error) {
 	return func(cmd *cobra.Command, args []string) (err error) {
-		toolctlAPI, err := api.New(localAPIFS, cmd, api.Remote)
+		toolctlAPI, err := api.New(localAPIFS, cmd)
 		if err != nil {
 			return err
 		}
@@ -87,8 +88,13 @@ func newRunUpgrade(
 			return
 		}
 
+		advisories, err := loadConfiguredAdvisoryDatabase()
+		if err != nil {
+			return
+		}
+
 		for _, tool := range allTools {
-			err = upgrade(toolctlWriter, toolctlAPI, installDir, tool, allTools)
+			err = upgrade(toolctlWriter, toolctlAPI, advisories, installDir, tool, allTools)
 			if err != nil {
 				return
 			}
@@ -99,7 +105,8 @@ func newRunUpgrade(
 }
 
 func upgrade(
-	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, installDir string,
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI,
+	advisories *advisory.Database, installDir string,
 	tool api.Tool, allTools []api.Tool,
 ) (err error) {
 	// Check if the tool is supported
@@ -134,7 +141,7 @@ func upgrade(
 	}
 
 	// Get the latest version
-	latestVersion, err := api.GetLatestVersion(toolctlAPI, tool)
+	latestVersion, err := getLatestVersion(toolctlAPI, tool)
 	if err != nil {
 		return
 	}
@@ -145,12 +152,20 @@ func upgrade(
 		return
 	}
 	installedVersion, err := getToolBinaryVersion(
-		installedToolPath, toolMeta.VersionArgs,
+		installedToolPath, toolMeta,
 	)
 	if err != nil {
 		return
 	}
 
+	// Point out known vulnerabilities, so that upgrading is not put off
+	if advisories != nil {
+		printAdvisories(
+			toolctlWriter, tool, allTools, installedVersion,
+			findAdvisories(*advisories, tool, installedVersion),
+		)
+	}
+
 	// Check if the installed version is newer than the latest version
 	if installedVersion.GreaterThan(latestVersion) {
 		fmt.Fprintln(
@@ -200,6 +215,25 @@ func upgrade(
 		return
 	}
 
+	// Check if the latest version is old enough to be trusted, and fall back to
+	// the newest version that is otherwise
+	version, tooRecent, err := getOldEnoughVersion(toolctlAPI, tool, latestVersion)
+	if err != nil {
+		return
+	}
+	if version == nil || !version.GreaterThan(installedVersion) {
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, "⏳ Skipping: "+tooRecent),
+		)
+		return
+	}
+	if tooRecent != "" {
+		fmt.Fprintln(toolctlWriter, prependToolName(tool, allTools, "⏳ "+tooRecent))
+		latestVersion = version
+		tool.Version = version.String()
+	}
+
 	// Start the upgrade
 	fmt.Fprintln(
 		toolctlWriter, prependToolName(
@@ -209,6 +243,13 @@ func upgrade(
 		),
 	)
 
+	if advisories != nil {
+		printAdvisories(
+			toolctlWriter, tool, allTools, latestVersion,
+			findAdvisories(*advisories, tool, latestVersion),
+		)
+	}
+
 	// Remove the installed tool
 	fmt.Fprintln(
 		toolctlWriter, prependToolName(
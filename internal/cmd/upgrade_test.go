@@ -2,6 +2,7 @@ package cmd_test
 
 import (
 	"testing"
+	"time"
 )
 
 func TestUpgradeCmd(t *testing.T) {
@@ -22,7 +23,9 @@ Flags:
   -h, --help   help for upgrade
 
 Global Flags:
-      --config string   path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --config string     path of the config file (default is $HOME/.config/toolctl/config.yaml)
+      --refresh           revalidate all cached API responses
+      --registry string   URL or path of the registry to use, e.g. file:///path/to/api/v0 (env TOOLCTL_REGISTRY)
 `
 
 	tests := []test{
@@ -78,6 +81,146 @@ echo "v0.1.0"
 👷 Removing v0.1.0 ...
 👷 Installing v0.1.1 ...
 🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, latest version released too recently",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339),
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+				},
+			},
+			config:  map[string]any{"MinReleaseAge": "12h"},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `⏳ Skipping: v0.1.1 was released 30 minutes ago, but the minimum release age is 12 hours
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, latest version released too recently, older version released long enough ago",
+			supportedTools: []supportedTool{
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.1",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-20 * 24 * time.Hour).UTC().Format(time.RFC3339),
+				},
+				{
+					name:        "toolctl-test-tool",
+					version:     "0.1.2",
+					tarGz:       true,
+					releaseDate: time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339),
+				},
+			},
+			apiIndex: true,
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+				},
+			},
+			config:  map[string]any{"MinReleaseAge": "12h"},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `⏳ v0.1.2 was released 30 minutes ago, but the minimum release age is 12 hours
+👷 Upgrading from v0.1.0 to v0.1.1 ...
+👷 Removing v0.1.0 ...
+👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, installed version is vulnerable",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+echo "v0.1.0"
+`,
+				},
+			},
+			config: map[string]any{
+				"AdvisoryDatabase": "testdata/advisories",
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `🚨 Vulnerable: v0.1.0 is affected by OSV-2024-0001 (Remote code execution), fixed in v0.1.1
+👷 Upgrading from v0.1.0 to v0.1.1 ...
+👷 Removing v0.1.0 ...
+👷 Installing v0.1.1 ...
+🎉 Successfully installed
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, version is detected in a sandbox",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+if [ -z "$TOOLCTL_TEST_SECRET" ] && [ "$(pwd)" = "$HOME" ] && ! read -r line; then
+  echo "v0.1.1"
+else
+  echo "v0.1.0"
+fi
+`,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOut: `✅ Already up to date (v0.1.1)
+`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "supported tool, version detection times out",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.1",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name: "toolctl-test-tool",
+					fileContents: `#!/bin/sh
+exec sleep 5
+`,
+				},
+			},
+			config: map[string]any{
+				"VersionTimeout": "100ms",
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantErr: true,
+			wantOut: `Error: ⏱️ Timed out: could not determine installed version within 100ms
 `,
 		},
 		// -------------------------------------------------------------------------
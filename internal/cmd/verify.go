@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/toolctl/toolctl/internal/api"
+)
+
+// verifyStatus is the result of verifying an installed tool.
+type verifyStatus int
+
+const (
+	verifyOK verifyStatus = iota
+	verifyModified
+	verifyMissing
+	verifyUnknown
+)
+
+func newVerifyCmd(toolctlWriter io.Writer, localAPIFS afero.Fs) *cobra.Command {
+	var verifyCmd = &cobra.Command{
+		Use:   "verify [TOOL...]",
+		Short: "Verify that installed tools have not been modified",
+		Args:  checkArgs(true),
+		Example: `  # Verify all installed tools
+  toolctl verify
+
+  # Verify a specific tool
+  toolctl verify kubectl`,
+		RunE: newRunVerify(toolctlWriter, localAPIFS),
+	}
+	return verifyCmd
+}
+
+func newRunVerify(
+	toolctlWriter io.Writer, localAPIFS afero.Fs,
+) func(*cobra.Command, []string) (err error) {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		toolctlAPI, err := api.New(localAPIFS, cmd)
+		if err != nil {
+			return err
+		}
+
+		// If no args were specified, verify all tools that have a receipt or
+		// are installed
+		if len(args) == 0 {
+			args, err = receiptToolNames()
+			if err != nil {
+				return
+			}
+
+			var meta api.Meta
+			meta, err = api.GetMeta(toolctlAPI)
+			if err != nil {
+				return
+			}
+			for _, toolName := range meta.Tools {
+				var installed bool
+				installed, err = isToolInstalled(toolName)
+				if err != nil {
+					return
+				}
+				if installed && !slices.Contains(args, toolName) {
+					args = append(args, toolName)
+				}
+			}
+			slices.Sort(args)
+
+			if len(args) == 0 {
+				err = fmt.Errorf("no supported tools installed")
+				return
+			}
+		}
+
+		allTools, err := ArgsToTools(args, runtime.GOOS, runtime.GOARCH, false)
+		if err != nil {
+			return fmt.Errorf(
+				"%w, try this instead:\n  toolctl verify %s",
+				err, strings.Join(stripVersionsFromArgs(args), " "),
+			)
+		}
+
+		var modified, missing int
+		for _, tool := range allTools {
+			var status verifyStatus
+			status, err = verifyTool(toolctlWriter, toolctlAPI, tool, allTools)
+			if err != nil {
+				return
+			}
+			switch status {
+			case verifyModified:
+				modified++
+			case verifyMissing:
+				missing++
+			}
+		}
+
+		if modified > 0 || missing > 0 {
+			err = fmt.Errorf(
+				"verification failed: %d modified, %d missing", modified, missing,
+			)
+		}
+
+		return
+	}
+}
+
+// verifyTool hashes the installed binary of a tool and compares the result
+// with the receipt, or with the binary digest recorded in the API.
+func verifyTool(
+	toolctlWriter io.Writer, toolctlAPI api.ToolctlAPI, tool api.Tool,
+	allTools []api.Tool,
+) (status verifyStatus, err error) {
+	found, r, err := loadReceipt(tool.Name)
+	if err != nil {
+		return
+	}
+
+	installedToolPath := r.InstallPath
+	if !found {
+		installedToolPath, err = which(tool.Name)
+		if err != nil {
+			return
+		}
+		if installedToolPath == "" {
+			status = verifyMissing
+			fmt.Fprintln(
+				toolctlWriter,
+				prependToolName(tool, allTools, fmt.Sprintf(
+					"❌ Missing: %s is not installed", tool.Name,
+				)),
+			)
+			return
+		}
+	}
+
+	binarySHA256, err := calculateFileSHA256(installedToolPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		err = nil
+		status = verifyMissing
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"❌ Missing: %s does not exist",
+				wrapInQuotesIfContainsSpace(installedToolPath),
+			)),
+		)
+		return
+	}
+
+	wantSHA256 := r.BinarySHA256
+	version := r.Version
+	if !found {
+		version, wantSHA256, err = apiBinarySHA256(toolctlAPI, tool, installedToolPath)
+		if err != nil {
+			return
+		}
+	}
+
+	switch {
+	case wantSHA256 == "":
+		status = verifyUnknown
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"🤷 Unknown: no digest found for %s",
+				wrapInQuotesIfContainsSpace(installedToolPath),
+			)),
+		)
+	case wantSHA256 != binarySHA256:
+		status = verifyModified
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"🚨 Modified: %s does not match v%s",
+				wrapInQuotesIfContainsSpace(installedToolPath), version,
+			)),
+		)
+	default:
+		status = verifyOK
+		fmt.Fprintln(
+			toolctlWriter,
+			prependToolName(tool, allTools, fmt.Sprintf(
+				"✅ Verified: %s matches v%s",
+				wrapInQuotesIfContainsSpace(installedToolPath), version,
+			)),
+		)
+	}
+
+	return
+}
+
+// apiBinarySHA256 determines the version of an installed tool and returns the
+// binary digest that the API has recorded for it, if any.
+func apiBinarySHA256(
+	toolctlAPI api.ToolctlAPI, tool api.Tool, installedToolPath string,
+) (version string, binarySHA256 string, err error) {
+	toolMeta, err := api.GetToolMeta(toolctlAPI, tool)
+	if err != nil {
+		return
+	}
+
+	installedVersion, err := getToolBinaryVersion(
+		installedToolPath, toolMeta,
+	)
+	if err != nil {
+		// Without a version, we don't know what to compare against
+		err = nil
+		return
+	}
+	version = installedVersion.String()
+
+	tool.Version = version
+	meta, err := api.GetToolPlatformVersionMeta(toolctlAPI, tool)
+	if err != nil {
+		if errors.Is(err, api.NotFoundError{}) {
+			err = nil
+		}
+		return
+	}
+	binarySHA256 = meta.BinarySHA256
+
+	return
+}
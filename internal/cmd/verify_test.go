@@ -0,0 +1,171 @@
+package cmd_test
+
+import (
+	"testing"
+)
+
+func TestVerifyCmd(t *testing.T) {
+	const toolContents = `#!/bin/sh
+echo "v0.1.0"
+`
+
+	tests := []test{
+		{
+			name: "receipt matches",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name:         "toolctl-test-tool",
+					fileContents: toolContents,
+				},
+			},
+			receipts: []testReceipt{
+				{
+					name:           "toolctl-test-tool",
+					version:        "0.1.0",
+					binaryContents: toolContents,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool"},
+			wantOutRegex: `^✅ Verified: .+/toolctl-test-tool matches v0.1.0
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "receipt does not match",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name:         "toolctl-test-tool",
+					fileContents: toolContents,
+				},
+			},
+			receipts: []testReceipt{
+				{
+					name:           "toolctl-test-tool",
+					version:        "0.1.0",
+					binaryContents: "original contents",
+				},
+			},
+			cliArgs: []string{},
+			wantErr: true,
+			wantOutRegex: `^🚨 Modified: .+/toolctl-test-tool does not match v0.1.0
+Error: verification failed: 1 modified, 0 missing
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "receipt for missing binary",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+			},
+			receipts: []testReceipt{
+				{
+					name:           "toolctl-test-tool",
+					version:        "0.1.0",
+					binaryContents: toolContents,
+				},
+			},
+			cliArgs: []string{},
+			wantErr: true,
+			wantOutRegex: `^❌ Missing: .+/toolctl-test-tool does not exist
+Error: verification failed: 0 modified, 1 missing
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "no receipt, binary digest in API matches",
+			supportedTools: []supportedTool{
+				{
+					name:              "toolctl-test-tool",
+					version:           "0.1.0",
+					tarGz:             true,
+					apiBinaryContents: toolContents,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name:         "toolctl-test-tool",
+					fileContents: toolContents,
+				},
+			},
+			cliArgs: []string{},
+			wantOutRegex: `^✅ Verified: .+/toolctl-test-tool matches v0.1.0
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "no receipt, no binary digest in API",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+				{
+					name:    "toolctl-other-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name:         "toolctl-test-tool",
+					fileContents: toolContents,
+				},
+			},
+			cliArgs: []string{"toolctl-test-tool", "toolctl-other-test-tool"},
+			wantErr: true,
+			wantOutRegex: `^\[toolctl-test-tool      \] 🤷 Unknown: no digest found for .+/toolctl-test-tool
+\[toolctl-other-test-tool\] ❌ Missing: toolctl-other-test-tool is not installed
+Error: verification failed: 0 modified, 1 missing
+$`,
+		},
+		// -------------------------------------------------------------------------
+		{
+			name: "tool not installed, other tools are still verified",
+			supportedTools: []supportedTool{
+				{
+					name:    "toolctl-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+				{
+					name:    "toolctl-other-test-tool",
+					version: "0.1.0",
+					tarGz:   true,
+				},
+			},
+			preinstalledTools: []preinstalledTool{
+				{
+					name:         "toolctl-test-tool",
+					fileContents: toolContents,
+				},
+			},
+			cliArgs: []string{"toolctl-other-test-tool", "toolctl-test-tool"},
+			wantErr: true,
+			wantOutRegex: `^\[toolctl-other-test-tool\] ❌ Missing: toolctl-other-test-tool is not installed
+\[toolctl-test-tool      \] 🤷 Unknown: no digest found for .+/toolctl-test-tool
+Error: verification failed: 0 modified, 1 missing
+$`,
+		},
+	}
+
+	runInstallUpgradeTests(t, tests, "verify")
+}
@@ -0,0 +1,262 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// HostAuth configures the credentials that are sent to a host.
+type HostAuth struct {
+	// Host is the host name, optionally with a port.
+	Host string
+	// Type is bearer (the default), basic or netrc. netrc reads the login and
+	// password for the host from the file in $NETRC, or ~/.netrc.
+	Type string
+	// Username is the user name for basic auth.
+	Username string
+	// Token is the bearer token, or the password for basic auth. Instead of
+	// putting it into the config, it can be read from an environment variable
+	// with TokenEnv, or from the output of a shell command with TokenCommand.
+	Token        string
+	TokenEnv     string
+	TokenCommand string
+}
+
+// hostCredentials are the credentials for a host, resolved on first use so
+// that token commands only run if they are needed.
+type hostCredentials struct {
+	auth     HostAuth
+	once     sync.Once
+	username string
+	password string
+	err      error
+}
+
+// newHostCredentials validates the auth config of a host.
+func newHostCredentials(auth HostAuth) (credentials *hostCredentials, err error) {
+	if auth.Host == "" {
+		err = fmt.Errorf("invalid auth: host is required")
+		return
+	}
+
+	var tokenSources int
+	for _, source := range []string{auth.Token, auth.TokenEnv, auth.TokenCommand} {
+		if source != "" {
+			tokenSources++
+		}
+	}
+
+	switch auth.Type {
+	case "", "bearer", "basic":
+		if auth.Type == "basic" && auth.Username == "" {
+			err = fmt.Errorf("invalid auth for %s: basic auth requires a username", auth.Host)
+			return
+		}
+		if tokenSources != 1 {
+			err = fmt.Errorf(
+				"invalid auth for %s: exactly one of token, tokenEnv and tokenCommand is required",
+				auth.Host,
+			)
+			return
+		}
+	case "netrc":
+		if tokenSources != 0 || auth.Username != "" {
+			err = fmt.Errorf(
+				"invalid auth for %s: netrc auth doesn't take a username or token", auth.Host,
+			)
+			return
+		}
+	default:
+		err = fmt.Errorf(
+			"invalid auth for %s: invalid type %s, must be bearer, basic or netrc",
+			auth.Host, auth.Type,
+		)
+		return
+	}
+
+	credentials = &hostCredentials{auth: auth}
+	return
+}
+
+// resolve reads the credentials from their source.
+func (h *hostCredentials) resolve(ctx context.Context) (username string, password string, err error) {
+	h.once.Do(func() {
+		switch {
+		case h.auth.Type == "netrc":
+			h.username, h.password, h.err = netrcCredentials(h.auth.Host)
+		case h.auth.TokenEnv != "":
+			h.password = os.Getenv(h.auth.TokenEnv)
+			if h.password == "" {
+				h.err = fmt.Errorf(
+					"no credentials for %s: environment variable %s is not set",
+					h.auth.Host, h.auth.TokenEnv,
+				)
+			}
+			h.username = h.auth.Username
+		case h.auth.TokenCommand != "":
+			// The output may contain the token, so only the exit status is
+			// part of the error
+			output, err := exec.CommandContext(ctx, "sh", "-c", h.auth.TokenCommand).Output()
+			if err != nil {
+				h.err = fmt.Errorf("no credentials for %s: token command failed: %w", h.auth.Host, err)
+				return
+			}
+			h.username, h.password = h.auth.Username, strings.TrimSpace(string(output))
+		default:
+			h.username, h.password = h.auth.Username, h.auth.Token
+		}
+	})
+	return h.username, h.password, h.err
+}
+
+// apply adds the credentials to a request.
+func (h *hostCredentials) apply(req *http.Request, username string, password string) {
+	if h.auth.Type == "basic" || h.auth.Type == "netrc" {
+		req.SetBasicAuth(username, password)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+password)
+}
+
+// netrcCredentials returns the login and password for a host from the netrc
+// file. Entries for the host take precedence over the default entry.
+func netrcCredentials(host string) (login string, password string, err error) {
+	netrcPath := os.Getenv("NETRC")
+	if netrcPath == "" {
+		var home string
+		home, err = os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		netrcPath = filepath.Join(home, ".netrc")
+	}
+
+	contents, err := os.ReadFile(netrcPath)
+	if err != nil {
+		err = fmt.Errorf("no credentials for %s: %w", host, err)
+		return
+	}
+
+	hostname, _, _ := strings.Cut(host, ":")
+
+	var found, inEntry, inDefault bool
+	var defaultLogin, defaultPassword string
+	fields := strings.Fields(string(contents))
+	for i := 0; i < len(fields); i++ {
+		var value string
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+
+		switch fields[i] {
+		case "machine":
+			if found {
+				return
+			}
+			inEntry = value == host || value == hostname
+			inDefault = false
+			found = inEntry
+			i++
+		case "default":
+			if found {
+				return
+			}
+			inEntry, inDefault = false, true
+		case "login", "password", "account":
+			switch {
+			case inEntry && fields[i] == "login":
+				login = value
+			case inEntry && fields[i] == "password":
+				password = value
+			case inDefault && fields[i] == "login":
+				defaultLogin = value
+			case inDefault && fields[i] == "password":
+				defaultPassword = value
+			}
+			i++
+		case "macdef":
+			// Macros run until the end of the file for our purposes
+			i = len(fields)
+		}
+	}
+
+	if !found {
+		if defaultLogin == "" && defaultPassword == "" {
+			err = fmt.Errorf("no credentials for %s in %s", host, netrcPath)
+			return
+		}
+		login, password = defaultLogin, defaultPassword
+	}
+	return
+}
+
+// credentialsFor returns the credentials for the host of a URL, if any. A host
+// with a port matches both entries with and without the port.
+func (c *Client) credentialsFor(u *url.URL) *hostCredentials {
+	for _, credentials := range c.credentials {
+		if credentials.auth.Host == u.Host {
+			return credentials
+		}
+	}
+	for _, credentials := range c.credentials {
+		if credentials.auth.Host == u.Hostname() {
+			return credentials
+		}
+	}
+	return nil
+}
+
+// addSecret remembers a credential, so that it can be redacted.
+func (c *Client) addSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	c.secretsMu.Lock()
+	defer c.secretsMu.Unlock()
+	for _, s := range c.secrets {
+		if s == secret {
+			return
+		}
+	}
+	c.secrets = append(c.secrets, secret)
+}
+
+// Redact masks all credentials that the client has used.
+func (c *Client) Redact(s string) string {
+	c.secretsMu.Lock()
+	defer c.secretsMu.Unlock()
+	for _, secret := range c.secrets {
+		s = strings.ReplaceAll(s, secret, "***")
+	}
+	return s
+}
+
+// RedactURL removes the password from a URL, and masks all credentials that
+// the shared client has used, so that the URL can be printed.
+func RedactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		rawURL = u.Redacted()
+	}
+	return Default().Redact(rawURL)
+}
+
+// redactedError is an error with all credentials masked in its message.
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e redactedError) Error() string {
+	return e.message
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,214 @@
+// Package httpclient contains the HTTP client that toolctl uses for all of its
+// requests, to the API as well as for downloads.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Config configures the HTTP client.
+type Config struct {
+	// ConnectTimeout limits how long establishing a connection, including the
+	// TLS handshake, may take. Zero means no timeout.
+	ConnectTimeout time.Duration
+	// ReadTimeout limits how long to wait for the response headers, and for
+	// each read of the response body. Zero means no timeout.
+	ReadTimeout time.Duration
+	// Proxy is the URL of the proxy to use. If empty, the proxy is taken from
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string
+	// CAFile is the path of a PEM file with certificates to trust in addition
+	// to the system certificates.
+	CAFile string
+	// UserAgent is sent with every request.
+	UserAgent string
+	// Auth configures the credentials for hosts that require them.
+	Auth []HostAuth
+}
+
+// Client is an HTTP client that adds the User-Agent and credentials to every
+// request, and cancels in-flight requests when its context is done. The
+// credentials are masked in all errors.
+type Client struct {
+	ctx         context.Context
+	httpClient  *http.Client
+	userAgent   string
+	credentials []*hostCredentials
+
+	secretsMu sync.Mutex
+	secrets   []string
+}
+
+// New returns a new client for the given context and config.
+func New(ctx context.Context, config Config) (client *Client, err error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialer := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil || config.ReadTimeout == 0 {
+			return conn, err
+		}
+		return &readTimeoutConn{Conn: conn, readTimeout: config.ReadTimeout}, nil
+	}
+	transport.TLSHandshakeTimeout = config.ConnectTimeout
+	transport.ResponseHeaderTimeout = config.ReadTimeout
+
+	if config.Proxy != "" {
+		var proxyURL *url.URL
+		proxyURL, err = url.Parse(config.Proxy)
+		if err != nil {
+			err = fmt.Errorf("invalid proxy: %w", err)
+			return
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if config.CAFile != "" {
+		var rootCAs *x509.CertPool
+		rootCAs, err = loadCAFile(config.CAFile)
+		if err != nil {
+			return
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client = &Client{
+		ctx:        ctx,
+		httpClient: &http.Client{Transport: transport},
+		userAgent:  config.UserAgent,
+	}
+
+	for _, auth := range config.Auth {
+		var credentials *hostCredentials
+		credentials, err = newHostCredentials(auth)
+		if err != nil {
+			return nil, err
+		}
+		client.credentials = append(client.credentials, credentials)
+
+		// Tokens from the config and the environment are known upfront, so
+		// they are redacted even before they are used
+		client.addSecret(auth.Token)
+		if auth.TokenEnv != "" {
+			client.addSecret(os.Getenv(auth.TokenEnv))
+		}
+	}
+	return
+}
+
+// loadCAFile returns the system certificates, extended with the certificates
+// in the given PEM file.
+func loadCAFile(caFile string) (rootCAs *x509.CertPool, err error) {
+	rootCAs, err = x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		err = fmt.Errorf("failed to load CA file: %w", err)
+		return
+	}
+	if !rootCAs.AppendCertsFromPEM(pem) {
+		err = fmt.Errorf("failed to load CA file %s: no certificates found", caFile)
+	}
+	return
+}
+
+// Do sends a request, using the context of the client.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(c.ctx)
+	if c.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if credentials := c.credentialsFor(req.URL); credentials != nil && req.Header.Get("Authorization") == "" {
+		username, password, err := credentials.resolve(c.ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.addSecret(password)
+		credentials.apply(req, username, password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, redactedError{err: err, message: c.Redact(err.Error())}
+	}
+	return resp, nil
+}
+
+// Get issues a GET request to the specified URL.
+func (c *Client) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Head issues a HEAD request to the specified URL.
+func (c *Client) Head(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// readTimeoutConn is a connection that fails reads that take longer than the
+// read timeout, so that stalled downloads don't hang forever.
+type readTimeoutConn struct {
+	net.Conn
+	readTimeout time.Duration
+}
+
+func (c *readTimeoutConn) Read(p []byte) (n int, err error) {
+	err = c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	if err != nil {
+		return
+	}
+	return c.Conn.Read(p)
+}
+
+var (
+	defaultClientMu sync.RWMutex
+	defaultClient   = &Client{ctx: context.Background(), httpClient: &http.Client{}}
+)
+
+// Default returns the shared client that is used by Get and Head.
+func Default() *Client {
+	defaultClientMu.RLock()
+	defer defaultClientMu.RUnlock()
+	return defaultClient
+}
+
+// SetDefault replaces the shared client that is used by Get and Head.
+func SetDefault(client *Client) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient = client
+}
+
+// Get issues a GET request to the specified URL, using the shared client.
+func Get(url string) (*http.Response, error) {
+	return Default().Get(url)
+}
+
+// Head issues a HEAD request to the specified URL, using the shared client.
+func Head(url string) (*http.Response, error) {
+	return Default().Head(url)
+}
@@ -0,0 +1,191 @@
+package httpclient_test
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toolctl/toolctl/internal/httpclient"
+)
+
+func TestClientUserAgent(t *testing.T) {
+	var gotUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	client, err := httpclient.New(context.Background(), httpclient.Config{UserAgent: "toolctl/v1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if gotUserAgent != "toolctl/v1.2.3" {
+		t.Errorf("User-Agent = %q, want %q", gotUserAgent, "toolctl/v1.2.3")
+	}
+}
+
+func TestClientContextCanceled(t *testing.T) {
+	requested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := httpclient.New(ctx, httpclient.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		<-requested
+		cancel()
+	}()
+	_, err = client.Get(server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Get() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClientReadTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client, err := httpclient.New(
+		context.Background(), httpclient.Config{ReadTimeout: 50 * time.Millisecond},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("Get() error = %v, want a timeout", err)
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	var gotURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := httpclient.New(context.Background(), httpclient.Config{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://toolctl.invalid/v0/meta.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if gotURL != "http://toolctl.invalid/v0/meta.yaml" {
+		t.Errorf("proxied URL = %q, want %q", gotURL, "http://toolctl.invalid/v0/meta.yaml")
+	}
+}
+
+func TestClientCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: server.Certificate().Raw,
+	}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the CA file, the certificate of the test server isn't trusted
+	client, err := httpclient.New(context.Background(), httpclient.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Get(server.URL)
+	if err == nil {
+		t.Fatal("Get() without CA file succeeded, want a certificate error")
+	}
+
+	client, err = httpclient.New(context.Background(), httpclient.Config{CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	emptyCAFile := filepath.Join(t.TempDir(), "empty.pem")
+	err := os.WriteFile(emptyCAFile, nil, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		config     httpclient.Config
+		wantErrStr string
+	}{
+		{
+			name:       "invalid proxy",
+			config:     httpclient.Config{Proxy: "http://proxy.invalid:port"},
+			wantErrStr: `invalid proxy: parse "http://proxy.invalid:port": invalid port ":port" after host`,
+		},
+		{
+			name:       "CA file without certificates",
+			config:     httpclient.Config{CAFile: emptyCAFile},
+			wantErrStr: "failed to load CA file " + emptyCAFile + ": no certificates found",
+		},
+		{
+			name: "basic auth without username",
+			config: httpclient.Config{
+				Auth: []httpclient.HostAuth{{Host: "example.com", Type: "basic", Token: "s3cret"}},
+			},
+			wantErrStr: "invalid auth for example.com: basic auth requires a username",
+		},
+		{
+			name: "auth with multiple tokens",
+			config: httpclient.Config{
+				Auth: []httpclient.HostAuth{{Host: "example.com", Token: "s3cret", TokenEnv: "TOKEN"}},
+			},
+			wantErrStr: "invalid auth for example.com: exactly one of token, tokenEnv and tokenCommand is required",
+		},
+		{
+			name: "auth with invalid type",
+			config: httpclient.Config{
+				Auth: []httpclient.HostAuth{{Host: "example.com", Type: "digest"}},
+			},
+			wantErrStr: "invalid auth for example.com: invalid type digest, must be bearer, basic or netrc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := httpclient.New(context.Background(), tt.config)
+			if err == nil || err.Error() != tt.wantErrStr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+// Package oci contains a minimal client for OCI registries, which toolctl uses
+// to store its API and tool binaries as OCI artifacts.
+package oci
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/toolctl/toolctl/internal/httpclient"
+)
+
+const (
+	// MediaTypeManifest is the media type of OCI image manifests.
+	MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeEmpty is the media type of the empty config of artifacts.
+	MediaTypeEmpty = "application/vnd.oci.empty.v1+json"
+	// AnnotationTitle is the annotation that holds the file name of a layer.
+	AnnotationTitle = "org.opencontainers.image.title"
+
+	// DefaultTag is the tag that is used if a reference has neither a tag nor
+	// a digest.
+	DefaultTag = "latest"
+)
+
+// emptyConfig is the contents of the empty config.
+var emptyConfig = []byte("{}")
+
+// Descriptor describes a blob.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	ArtifactType  string       `json:"artifactType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// NewManifest returns an empty artifact manifest.
+func NewManifest(artifactType string) Manifest {
+	return Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeManifest,
+		ArtifactType:  artifactType,
+		Config: Descriptor{
+			MediaType: MediaTypeEmpty,
+			Digest:    Digest(emptyConfig),
+			Size:      int64(len(emptyConfig)),
+		},
+		Layers: []Descriptor{},
+	}
+}
+
+// Digest returns the SHA256 digest of the contents.
+func Digest(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Reference points to a repository in a registry, and optionally to a tag or
+// digest in it. References are written as URLs, e.g.
+// oci://registry.example.com/toolctl/api:v0 or
+// oci://registry.example.com/tools@sha256:<hex>#k9s.tar.gz. The oci+http
+// scheme is for registries without TLS.
+type Reference struct {
+	PlainHTTP  bool
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+	// FileName is taken from the URL fragment. Blobs have no names, so it is
+	// the only way to tell e.g. archives from binaries.
+	FileName string
+}
+
+// IsReference returns true if the URL has one of the OCI schemes.
+func IsReference(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "oci://") || strings.HasPrefix(rawURL, "oci+http://")
+}
+
+// ParseReference parses an oci:// or oci+http:// URL.
+func ParseReference(rawURL string) (ref Reference, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		err = fmt.Errorf("invalid OCI reference %s: %w", rawURL, err)
+		return
+	}
+	switch u.Scheme {
+	case "oci":
+	case "oci+http":
+		ref.PlainHTTP = true
+	default:
+		err = fmt.Errorf("invalid OCI reference %s: scheme must be oci or oci+http", rawURL)
+		return
+	}
+
+	ref.Registry = u.Host
+	ref.Repository = strings.Trim(u.Path, "/")
+	ref.FileName = u.Fragment
+	if repository, digest, found := strings.Cut(ref.Repository, "@"); found {
+		ref.Repository, ref.Digest = repository, digest
+	} else if i := strings.LastIndex(ref.Repository, ":"); i > strings.LastIndex(ref.Repository, "/") {
+		ref.Repository, ref.Tag = ref.Repository[:i], ref.Repository[i+1:]
+	}
+
+	if ref.Registry == "" || ref.Repository == "" {
+		err = fmt.Errorf("invalid OCI reference %s: registry and repository are required", rawURL)
+		return
+	}
+	if ref.Digest != "" && !strings.HasPrefix(ref.Digest, "sha256:") {
+		err = fmt.Errorf("invalid OCI reference %s: only sha256 digests are supported", rawURL)
+		return
+	}
+	return
+}
+
+// Reference returns the tag or digest of the reference, DefaultTag if it has
+// neither.
+func (r Reference) Reference() string {
+	switch {
+	case r.Digest != "":
+		return r.Digest
+	case r.Tag != "":
+		return r.Tag
+	default:
+		return DefaultTag
+	}
+}
+
+// Client talks to a repository in an OCI registry, using the shared HTTP
+// client. Registries that require bearer tokens are supported, with the token
+// being requested from the realm of the registry's challenge.
+type Client struct {
+	baseURL    *url.URL
+	repository string
+	token      string
+}
+
+// NewClient returns a client for the repository of the reference.
+func NewClient(ref Reference) *Client {
+	scheme := "https"
+	if ref.PlainHTTP {
+		scheme = "http"
+	}
+	return &Client{
+		baseURL:    &url.URL{Scheme: scheme, Host: ref.Registry},
+		repository: ref.Repository,
+	}
+}
+
+// GetManifest fetches the manifest with the given tag or digest.
+func (c *Client) GetManifest(reference string) (found bool, manifest Manifest, err error) {
+	resp, err := c.do(
+		http.MethodGet, c.repositoryURL("manifests", reference),
+		http.Header{"Accept": {MediaTypeManifest}}, nil,
+	)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = statusError(resp)
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&manifest)
+	if err != nil {
+		err = fmt.Errorf("invalid manifest %s: %w", reference, err)
+		return
+	}
+	found = true
+	return
+}
+
+// PutManifest pushes a manifest under the given tag. The config blob of the
+// manifest is pushed as well, as registries reject manifests with missing
+// blobs.
+func (c *Client) PutManifest(tag string, manifest Manifest) (err error) {
+	if manifest.Config.Digest == Digest(emptyConfig) {
+		_, err = c.PushBlob(MediaTypeEmpty, emptyConfig)
+		if err != nil {
+			return
+		}
+	}
+
+	contents, err := json.Marshal(manifest)
+	if err != nil {
+		return
+	}
+	resp, err := c.do(
+		http.MethodPut, c.repositoryURL("manifests", tag),
+		http.Header{"Content-Type": {MediaTypeManifest}}, contents,
+	)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		err = statusError(resp)
+	}
+	return
+}
+
+// OpenBlob opens the blob with the given digest for reading. The caller must
+// verify the digest of the contents.
+func (c *Client) OpenBlob(digest string) (body io.ReadCloser, err error) {
+	resp, err := c.do(http.MethodGet, c.repositoryURL("blobs", digest), nil, nil)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		err = statusError(resp)
+		return
+	}
+	return resp.Body, nil
+}
+
+// GetBlob fetches the blob with the given digest, and verifies its digest.
+func (c *Client) GetBlob(digest string) (contents []byte, err error) {
+	body, err := c.OpenBlob(digest)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	contents, err = io.ReadAll(body)
+	if err != nil {
+		return
+	}
+	if got := Digest(contents); got != digest {
+		err = fmt.Errorf("digest mismatch, wanted %s, got %s", digest, got)
+		contents = nil
+	}
+	return
+}
+
+// PushBlob uploads a blob, unless the registry already has it.
+func (c *Client) PushBlob(mediaType string, contents []byte) (descriptor Descriptor, err error) {
+	descriptor = Descriptor{MediaType: mediaType, Digest: Digest(contents), Size: int64(len(contents))}
+
+	resp, err := c.do(http.MethodHead, c.repositoryURL("blobs", descriptor.Digest), nil, nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return
+	}
+
+	resp, err = c.do(http.MethodPost, c.repositoryURL("blobs", "uploads")+"/", nil, nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		err = statusError(resp)
+		return
+	}
+
+	uploadURL, err := c.baseURL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		err = fmt.Errorf("invalid upload location: %w", err)
+		return
+	}
+	query := uploadURL.Query()
+	query.Set("digest", descriptor.Digest)
+	uploadURL.RawQuery = query.Encode()
+
+	resp, err = c.do(
+		http.MethodPut, uploadURL.String(),
+		http.Header{"Content-Type": {"application/octet-stream"}}, contents,
+	)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		err = statusError(resp)
+	}
+	return
+}
+
+// repositoryURL returns the URL of a manifest or blob in the repository.
+func (c *Client) repositoryURL(kind string, reference string) string {
+	return c.baseURL.JoinPath("v2", c.repository, kind, reference).String()
+}
+
+// do sends a request. If the registry responds with a bearer token challenge,
+// a token is requested and the request is retried with it.
+func (c *Client) do(
+	method string, requestURL string, header http.Header, body []byte,
+) (resp *http.Response, err error) {
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		req, err = http.NewRequest(method, requestURL, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err = httpclient.Default().Do(req)
+		if err != nil {
+			return
+		}
+
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 ||
+			!strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			return
+		}
+		resp.Body.Close()
+
+		c.token, err = fetchToken(challenge)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchToken requests a token from the realm of a bearer token challenge. The
+// credentials for the realm's host are added by the shared HTTP client.
+func fetchToken(challenge string) (token string, err error) {
+	params := parseChallenge(challenge)
+	realmURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		err = fmt.Errorf("invalid registry auth challenge: %s", challenge)
+		return
+	}
+	query := realmURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realmURL.RawQuery = query.Encode()
+
+	resp, err := httpclient.Get(realmURL.String())
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("failed to get registry token: %w", statusError(resp))
+		return
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokenResponse)
+	if err != nil {
+		err = fmt.Errorf("invalid registry token response: %w", err)
+		return
+	}
+
+	token = tokenResponse.Token
+	if token == "" {
+		token = tokenResponse.AccessToken
+	}
+	if token == "" {
+		err = fmt.Errorf("invalid registry token response: no token")
+	}
+	return
+}
+
+// parseChallenge parses the parameters of a WWW-Authenticate header, e.g.
+// Bearer realm="https://auth.example.com/token",service="registry".
+func parseChallenge(challenge string) (params map[string]string) {
+	params = map[string]string{}
+	_, rest, _ := strings.Cut(challenge, " ")
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, ", "), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return
+}
+
+// statusError returns an error for an unexpected response status.
+func statusError(resp *http.Response) error {
+	return fmt.Errorf(
+		"unexpected status code %d for %s %s",
+		resp.StatusCode, resp.Request.Method, httpclient.RedactURL(resp.Request.URL.String()),
+	)
+}
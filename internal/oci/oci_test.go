@@ -0,0 +1,136 @@
+package oci_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toolctl/toolctl/internal/oci"
+	"github.com/toolctl/toolctl/internal/oci/ocitest"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name       string
+		rawURL     string
+		want       oci.Reference
+		wantErrStr string
+	}{
+		{
+			name:   "repository",
+			rawURL: "oci://registry.example.com/toolctl/api",
+			want:   oci.Reference{Registry: "registry.example.com", Repository: "toolctl/api"},
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:   "tag and port",
+			rawURL: "oci+http://localhost:5000/toolctl/api:v0",
+			want: oci.Reference{
+				PlainHTTP: true, Registry: "localhost:5000", Repository: "toolctl/api", Tag: "v0",
+			},
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:   "digest and file name",
+			rawURL: "oci://registry.example.com/tools@sha256:abc#k9s.tar.gz",
+			want: oci.Reference{
+				Registry: "registry.example.com", Repository: "tools", Digest: "sha256:abc",
+				FileName: "k9s.tar.gz",
+			},
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:       "unsupported digest",
+			rawURL:     "oci://registry.example.com/tools@sha512:abc",
+			wantErrStr: "invalid OCI reference oci://registry.example.com/tools@sha512:abc: only sha256 digests are supported",
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:       "no repository",
+			rawURL:     "oci://registry.example.com",
+			wantErrStr: "invalid OCI reference oci://registry.example.com: registry and repository are required",
+		},
+		// -----------------------------------------------------------------------
+		{
+			name:       "wrong scheme",
+			rawURL:     "https://registry.example.com/tools",
+			wantErrStr: "invalid OCI reference https://registry.example.com/tools: scheme must be oci or oci+http",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := oci.ParseReference(tt.rawURL)
+			if tt.wantErrStr != "" {
+				if err == nil || err.Error() != tt.wantErrStr {
+					t.Errorf("ParseReference() error = %v, want %v", err, tt.wantErrStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseReference() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	registry.RequireToken = true
+	defer registry.Close()
+
+	ref, err := oci.ParseReference(registry.Reference("toolctl/api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := oci.NewClient(ref)
+
+	found, _, err := client.GetManifest(ref.Reference())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("GetManifest() found a manifest in an empty repository")
+	}
+
+	layer, err := client.PushBlob("text/plain", []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, pushed := registry.Blob(layer.Digest); !pushed {
+		t.Errorf("PushBlob() didn't push %s", layer.Digest)
+	}
+
+	manifest := oci.NewManifest("application/vnd.toolctl.test")
+	manifest.Layers = append(manifest.Layers, layer)
+	err = client.PutManifest(ref.Reference(), manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, gotManifest, err := client.GetManifest(ref.Reference())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("GetManifest() didn't find the pushed manifest")
+	}
+	if diff := cmp.Diff(manifest, gotManifest); diff != "" {
+		t.Errorf("GetManifest() mismatch (-want +got):\n%s", diff)
+	}
+
+	contents, err := client.GetBlob(gotManifest.Layers[0].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "hello" {
+		t.Errorf("GetBlob() = %q, want %q", contents, "hello")
+	}
+
+	_, err = client.GetBlob(oci.Digest([]byte("missing")))
+	if err == nil {
+		t.Error("GetBlob() of a missing blob should fail")
+	}
+}
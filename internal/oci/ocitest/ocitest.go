@@ -0,0 +1,191 @@
+// Package ocitest provides an in-process fake OCI registry for tests.
+package ocitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/toolctl/toolctl/internal/oci"
+)
+
+// Token is the bearer token that the registry issues if it requires tokens.
+const Token = "ocitest-token"
+
+// Registry is a fake OCI registry that keeps all blobs and manifests in
+// memory. It implements the parts of the distribution API that toolctl uses.
+type Registry struct {
+	Server *httptest.Server
+	// RequireToken makes the registry answer requests without a token with a
+	// bearer token challenge, like most public registries do.
+	RequireToken bool
+
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+// NewRegistry starts a fake registry. It must be closed after use.
+func NewRegistry() *Registry {
+	r := &Registry{
+		blobs:     map[string][]byte{},
+		manifests: map[string][]byte{},
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// Close shuts the registry down.
+func (r *Registry) Close() {
+	r.Server.Close()
+}
+
+// Reference returns the oci+http:// URL of a repository in the registry.
+func (r *Registry) Reference(repository string) string {
+	return "oci+http://" + strings.TrimPrefix(r.Server.URL, "http://") + "/" + repository
+}
+
+// PushBlob stores a blob and returns its digest.
+func (r *Registry) PushBlob(contents []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := oci.Digest(contents)
+	r.blobs[digest] = contents
+	return digest
+}
+
+// Blob returns a blob, so that tests can check what was pushed.
+func (r *Registry) Blob(digest string) (contents []byte, found bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contents, found = r.blobs[digest]
+	return
+}
+
+// Manifest returns the manifest that a tag or digest refers to, so that tests
+// can check what was pushed.
+func (r *Registry) Manifest(repository string, reference string) (contents []byte, found bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contents, found = r.manifests[repository+"@"+reference]
+	return
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": Token})
+		return
+	}
+	if r.RequireToken && req.Header.Get("Authorization") != "Bearer "+Token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer realm="%s/token",service="ocitest",scope="repository:toolctl:pull,push"`,
+			r.Server.URL,
+		))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case path == "" || path == "/":
+		w.WriteHeader(http.StatusOK)
+	case strings.Contains(path, "/blobs/uploads/"):
+		r.serveUpload(w, req, path)
+	case strings.Contains(path, "/blobs/"):
+		_, digest, _ := strings.Cut(path, "/blobs/")
+		r.serveContents(w, req, r.blobs[digest], "application/octet-stream", digest)
+	case strings.Contains(path, "/manifests/"):
+		repository, reference, _ := strings.Cut(path, "/manifests/")
+		r.serveManifest(w, req, repository, reference)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// serveUpload implements monolithic blob uploads: a POST to start the upload,
+// followed by a PUT with the contents and digest.
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request, path string) {
+	repository, uploadID, _ := strings.Cut(path, "/blobs/uploads/")
+	switch req.Method {
+	case http.MethodPost:
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", repository, r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		if uploadID == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		contents, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		digest := req.URL.Query().Get("digest")
+		if oci.Digest(contents) != digest {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = contents
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// serveManifest stores and serves manifests by tag and by digest.
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	if req.Method != http.MethodPut {
+		contents := r.manifests[repository+"@"+reference]
+		r.serveContents(w, req, contents, oci.MediaTypeManifest, oci.Digest(contents))
+		return
+	}
+
+	contents, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var manifest oci.Manifest
+	if json.Unmarshal(contents, &manifest) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for _, descriptor := range append([]oci.Descriptor{manifest.Config}, manifest.Layers...) {
+		if _, ok := r.blobs[descriptor.Digest]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	digest := oci.Digest(contents)
+	r.manifests[repository+"@"+reference] = contents
+	r.manifests[repository+"@"+digest] = contents
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (r *Registry) serveContents(
+	w http.ResponseWriter, req *http.Request, contents []byte, contentType string, digest string,
+) {
+	if contents == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+	w.Header().Set("Docker-Content-Digest", digest)
+	if req.Method == http.MethodGet {
+		_, _ = w.Write(contents)
+	}
+}
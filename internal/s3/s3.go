@@ -0,0 +1,285 @@
+// Package s3 contains a minimal client for S3-compatible object storage,
+// which toolctl uses to read and publish its API.
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toolctl/toolctl/internal/httpclient"
+)
+
+// DefaultRegion is used if neither the config nor the environment specify a
+// region.
+const DefaultRegion = "us-east-1"
+
+// Credentials are the credentials that requests are signed with. Requests
+// without credentials are sent unsigned, which works for public buckets.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialsFromEnv reads the credentials from the standard AWS environment
+// variables.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Config configures the client.
+type Config struct {
+	// Endpoint is the URL of the S3-compatible service. It defaults to the
+	// AWS endpoint of the region.
+	Endpoint    string
+	Region      string
+	Bucket      string
+	Credentials Credentials
+}
+
+// Client reads and writes the objects of a bucket, using path-style requests
+// and the shared HTTP client.
+type Client struct {
+	endpoint    *url.URL
+	region      string
+	bucket      string
+	credentials Credentials
+	now         func() time.Time
+}
+
+// New returns a client for the bucket in the config.
+func New(config Config) (client *Client, err error) {
+	if config.Bucket == "" {
+		err = fmt.Errorf("invalid S3 config: bucket is required")
+		return
+	}
+	if config.Region == "" {
+		config.Region = DefaultRegion
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		err = fmt.Errorf("invalid S3 endpoint %s", config.Endpoint)
+		return
+	}
+
+	client = &Client{
+		endpoint:    endpoint,
+		region:      config.Region,
+		bucket:      config.Bucket,
+		credentials: config.Credentials,
+		now:         time.Now,
+	}
+	return
+}
+
+// GetObject fetches an object. A missing object is not an error. S3 only
+// answers with 404 NoSuchKey for missing objects if the caller has the
+// s3:ListBucket permission, and with 403 AccessDenied otherwise, so it is
+// required in addition to s3:GetObject.
+func (c *Client) GetObject(key string) (found bool, contents []byte, err error) {
+	resp, err := c.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		err = fmt.Errorf(
+			"%w (reading the bucket requires the s3:ListBucket permission, "+
+				"as S3 denies access to missing objects without it)",
+			statusError(resp),
+		)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = statusError(resp)
+		return
+	}
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	found = true
+	return
+}
+
+// PutObject creates or replaces an object.
+func (c *Client) PutObject(key string, contents []byte, contentType string) (err error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(http.MethodPut, key, header, contents)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = statusError(resp)
+	}
+	return
+}
+
+// do sends a signed request for an object.
+func (c *Client) do(
+	method string, key string, header http.Header, body []byte,
+) (resp *http.Response, err error) {
+	objectURL := c.endpoint.JoinPath(c.bucket, key)
+	req, err := http.NewRequest(method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if c.credentials.AccessKeyID != "" {
+		sign(req, body, c.credentials, c.region, c.now())
+	}
+	return httpclient.Default().Do(req)
+}
+
+// sign adds an AWS Signature Version 4 to a request. All headers that are set
+// at this point are signed.
+func sign(req *http.Request, body []byte, credentials Credentials, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := hashHex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalQuery returns the query with sorted and escaped keys and values.
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, escape(key)+"="+escape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// escapePath escapes each segment of a path.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes everything but the unreserved characters, as SigV4
+// requires.
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// statusError returns an error for an unexpected response status, with the
+// error code from the response body if there is one.
+func statusError(resp *http.Response) error {
+	err := fmt.Errorf(
+		"unexpected status code %d for %s %s",
+		resp.StatusCode, resp.Request.Method, httpclient.RedactURL(resp.Request.URL.String()),
+	)
+
+	var s3Error struct {
+		Code    string
+		Message string
+	}
+	if xml.NewDecoder(resp.Body).Decode(&s3Error) == nil && s3Error.Code != "" {
+		err = fmt.Errorf("%w: %s: %s", err, s3Error.Code, s3Error.Message)
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package s3_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toolctl/toolctl/internal/s3"
+	"github.com/toolctl/toolctl/internal/s3/s3test"
+)
+
+func TestGetObject(t *testing.T) {
+	tests := []struct {
+		name              string
+		key               string
+		accessKeyID       string
+		withoutListBucket bool
+		wantFound         bool
+		wantContents      string
+		wantErrStr        string
+	}{
+		{
+			name:         "existing object",
+			key:          "api/v0/meta.yaml",
+			accessKeyID:  "AKIATOOLCTLTEST",
+			wantFound:    true,
+			wantContents: "tools: []\n",
+		},
+		{
+			name:        "missing object",
+			key:         "api/v0/missing.yaml",
+			accessKeyID: "AKIATOOLCTLTEST",
+		},
+		{
+			name:              "missing object without s3:ListBucket",
+			key:               "api/v0/missing.yaml",
+			accessKeyID:       "AKIATOOLCTLTEST",
+			withoutListBucket: true,
+			wantErrStr: ": AccessDenied: Forbidden (reading the bucket requires the s3:ListBucket permission, " +
+				"as S3 denies access to missing objects without it)",
+		},
+		{
+			name:        "wrong credentials",
+			key:         "api/v0/meta.yaml",
+			accessKeyID: "AKIAWRONG",
+			wantErrStr:  ": AccessDenied: Forbidden (reading the bucket requires the s3:ListBucket permission",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := s3test.NewServer("AKIATOOLCTLTEST")
+			defer server.Close()
+			server.WithoutListBucket = tt.withoutListBucket
+			server.PutObject("toolctl", "api/v0/meta.yaml", []byte("tools: []\n"))
+
+			client, err := s3.New(s3.Config{
+				Endpoint: server.URL(),
+				Bucket:   "toolctl",
+				Credentials: s3.Credentials{
+					AccessKeyID: tt.accessKeyID, SecretAccessKey: "secret",
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found, contents, err := client.GetObject(tt.key)
+			if tt.wantErrStr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrStr) {
+					t.Errorf("GetObject() error = %v, want %v", err, tt.wantErrStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != tt.wantFound {
+				t.Errorf("GetObject() found = %v, want %v", found, tt.wantFound)
+			}
+			if diff := cmp.Diff(tt.wantContents, string(contents)); diff != "" {
+				t.Errorf("GetObject() contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPutObject(t *testing.T) {
+	server := s3test.NewServer("AKIATOOLCTLTEST")
+	defer server.Close()
+
+	client, err := s3.New(s3.Config{
+		Endpoint:    server.URL(),
+		Bucket:      "toolctl",
+		Credentials: s3.Credentials{AccessKeyID: "AKIAWRONG", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.PutObject("api/v0/meta.yaml", []byte("tools: []\n"), "application/yaml")
+	wantErrStr := "unexpected status code 403 for PUT " + server.URL() +
+		"/toolctl/api/v0/meta.yaml: AccessDenied: Forbidden"
+	if err == nil || err.Error() != wantErrStr {
+		t.Errorf("PutObject() error = %v, want %v", err, wantErrStr)
+	}
+	if _, found := server.Object("toolctl", "api/v0/meta.yaml"); found {
+		t.Error("PutObject() stored the object despite the error")
+	}
+}
@@ -0,0 +1,110 @@
+// Package s3test provides an in-process fake S3-compatible server for tests.
+package s3test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is a fake S3 server that keeps all objects in memory. If an access key
+// ID is set, requests must carry a SigV4 authorization for it with a matching
+// payload hash. The signature itself is not verified.
+type Server struct {
+	Server      *httptest.Server
+	AccessKeyID string
+	// WithoutListBucket makes the server answer requests for missing objects
+	// with AccessDenied, like S3 does for callers without s3:ListBucket.
+	WithoutListBucket bool
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewServer starts a fake S3 server. It must be closed after use.
+func NewServer(accessKeyID string) *Server {
+	s := &Server{AccessKeyID: accessKeyID, objects: map[string][]byte{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.Server.Close()
+}
+
+// URL returns the endpoint URL of the server.
+func (s *Server) URL() string {
+	return s.Server.URL
+}
+
+// Object returns an object, so that tests can check what was written.
+func (s *Server) Object(bucket string, key string) (contents []byte, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contents, found = s.objects[bucket+"/"+key]
+	return
+}
+
+// PutObject stores an object.
+func (s *Server) PutObject(bucket string, key string, contents []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[bucket+"/"+key] = contents
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "InternalError")
+		return
+	}
+
+	if s.AccessKeyID != "" {
+		if !strings.HasPrefix(
+			req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/",
+		) {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		sum := sha256.Sum256(body)
+		if req.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+			return
+		}
+	}
+
+	key := strings.TrimPrefix(req.URL.Path, "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Method {
+	case http.MethodGet:
+		contents, ok := s.objects[key]
+		if !ok && s.WithoutListBucket {
+			writeError(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		_, _ = w.Write(contents)
+	case http.MethodPut:
+		s.objects[key] = body
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, http.StatusText(status))
+}
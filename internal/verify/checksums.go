@@ -0,0 +1,75 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// bsdChecksumLine matches lines in the BSD format, e.g. as written by
+// `shasum --tag`: SHA256 (file.tar.gz) = <hash>
+var bsdChecksumLine = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9a-fA-F]{64})$`)
+
+// ChecksumForFile returns the SHA256 checksum for the given file name from the
+// contents of a checksum file, like checksums.txt or SHA256SUMS. Both the GNU
+// format written by sha256sum and the BSD format are supported. The file name
+// is the path of the file relative to the checksum file. If no entry matches
+// it, entries are matched by their base name, as long as only one does, since
+// checksum files may list the same file name in several directories.
+func ChecksumForFile(checksums []byte, fileName string) (sha256 string, err error) {
+	fileName = path.Clean(fileName)
+	baseName := path.Base(fileName)
+	baseNameHashes := map[string]struct{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var hash, name string
+		if match := bsdChecksumLine.FindStringSubmatch(line); match != nil {
+			name, hash = match[1], match[2]
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) != 2 || len(fields[0]) != 64 {
+				continue
+			}
+			// An asterisk marks binary mode in the GNU format
+			hash, name = fields[0], strings.TrimPrefix(fields[1], "*")
+		}
+		hash = strings.ToLower(hash)
+
+		name = path.Clean(name)
+		if name == fileName {
+			sha256 = hash
+			return
+		}
+		if path.Base(name) == baseName {
+			baseNameHashes[hash] = struct{}{}
+			sha256 = hash
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		sha256 = ""
+		return
+	}
+
+	switch len(baseNameHashes) {
+	case 0:
+		err = fmt.Errorf("checksum for %s could not be found", fileName)
+	case 1:
+	default:
+		sha256 = ""
+		err = fmt.Errorf(
+			"checksum for %s is ambiguous, the checksum file lists it in several directories",
+			fileName,
+		)
+	}
+	return
+}
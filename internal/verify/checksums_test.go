@@ -0,0 +1,77 @@
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/toolctl/toolctl/internal/verify"
+)
+
+func TestChecksumForFile(t *testing.T) {
+	const (
+		hash      = "cb3174cf3910a0d711a61059363aad6a30b7dcc1125be8027f20907a6612bf24"
+		otherHash = "0f1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	)
+
+	tests := []struct {
+		name       string
+		checksums  string
+		fileName   string
+		want       string
+		wantErrStr string
+	}{
+		{
+			name: "GNU format",
+			checksums: otherHash + "  toolctl-test-tool_linux_arm64.tar.gz\n" +
+				hash + "  toolctl-test-tool_linux_amd64.tar.gz\n",
+			fileName: "toolctl-test-tool_linux_amd64.tar.gz",
+			want:     hash,
+		},
+		{
+			name:      "GNU format in binary mode with directory",
+			checksums: hash + " *./dist/toolctl-test-tool_linux_amd64.tar.gz\n",
+			fileName:  "toolctl-test-tool_linux_amd64.tar.gz",
+			want:      hash,
+		},
+		{
+			name:      "BSD format",
+			checksums: "SHA256 (toolctl-test-tool_linux_amd64.tar.gz) = " + hash + "\n",
+			fileName:  "toolctl-test-tool_linux_amd64.tar.gz",
+			want:      hash,
+		},
+		{
+			name: "same file name in several directories",
+			checksums: otherHash + "  darwin/toolctl-test-tool\n" +
+				hash + "  linux/toolctl-test-tool\n",
+			fileName: "linux/toolctl-test-tool",
+			want:     hash,
+		},
+		{
+			name: "same file name in several directories, base name only",
+			checksums: otherHash + "  darwin/toolctl-test-tool\n" +
+				hash + "  linux/toolctl-test-tool\n",
+			fileName:   "toolctl-test-tool",
+			wantErrStr: "checksum for toolctl-test-tool is ambiguous, the checksum file lists it in several directories",
+		},
+		{
+			name:       "missing file",
+			checksums:  otherHash + "  toolctl-test-tool_linux_arm64.tar.gz\n",
+			fileName:   "toolctl-test-tool_linux_amd64.tar.gz",
+			wantErrStr: "checksum for toolctl-test-tool_linux_amd64.tar.gz could not be found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verify.ChecksumForFile([]byte(tt.checksums), tt.fileName)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("ChecksumForFile() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil && err.Error() != tt.wantErrStr {
+				t.Errorf("ChecksumForFile() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if got != tt.want {
+				t.Errorf("ChecksumForFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
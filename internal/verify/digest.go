@@ -0,0 +1,96 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// newDigestHashes maps the supported digest algorithms to their constructors.
+var newDigestHashes = map[string]func() hash.Hash{
+	"blake2b": func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	},
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// DigestAlgorithms returns the names of the supported digest algorithms.
+func DigestAlgorithms() []string {
+	algorithms := make([]string, 0, len(newDigestHashes))
+	for algorithm := range newDigestHashes {
+		algorithms = append(algorithms, algorithm)
+	}
+	slices.Sort(algorithms)
+	return algorithms
+}
+
+// Digests calculates the hex-encoded digests of the data read from the given
+// reader, for all given algorithms at once.
+func Digests(data io.Reader, algorithms []string) (digests map[string]string, err error) {
+	hashes := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		algorithm = strings.ToLower(algorithm)
+		newHash, ok := newDigestHashes[algorithm]
+		if !ok {
+			err = fmt.Errorf(
+				"unsupported digest algorithm %s, supported are: %s",
+				algorithm, strings.Join(DigestAlgorithms(), ", "),
+			)
+			return
+		}
+		if _, exists := hashes[algorithm]; exists {
+			continue
+		}
+		hashes[algorithm] = newHash()
+		writers = append(writers, hashes[algorithm])
+	}
+
+	_, err = io.Copy(io.MultiWriter(writers...), data)
+	if err != nil {
+		return
+	}
+
+	digests = make(map[string]string, len(hashes))
+	for algorithm, h := range hashes {
+		digests[algorithm] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return
+}
+
+// CheckDigests calculates the digests of the data read from the given reader
+// and compares them with the expected digests. All expected digests have to
+// match, unknown algorithms are treated as a failure.
+func CheckDigests(data io.Reader, expected map[string]string) error {
+	algorithms := make([]string, 0, len(expected))
+	for algorithm := range expected {
+		algorithms = append(algorithms, algorithm)
+	}
+	slices.Sort(algorithms)
+
+	digests, err := Digests(data, algorithms)
+	if err != nil {
+		return err
+	}
+
+	for _, algorithm := range algorithms {
+		want := strings.ToLower(expected[algorithm])
+		got := digests[strings.ToLower(algorithm)]
+		if want != got {
+			return fmt.Errorf(
+				"%s hash mismatch, wanted %s, got %s",
+				strings.ToUpper(algorithm), want, got,
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package verify_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toolctl/toolctl/internal/verify"
+)
+
+const (
+	abcSHA256 = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	abcSHA512 = "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a" +
+		"2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"
+	abcBLAKE2b = "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d1" +
+		"7d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"
+)
+
+func TestDigests(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithms []string
+		want       map[string]string
+		wantErrStr string
+	}{
+		{
+			name:       "all algorithms",
+			algorithms: []string{"sha256", "SHA512", "blake2b"},
+			want: map[string]string{
+				"sha256":  abcSHA256,
+				"sha512":  abcSHA512,
+				"blake2b": abcBLAKE2b,
+			},
+		},
+		{
+			name:       "unsupported algorithm",
+			algorithms: []string{"sha256", "md5"},
+			wantErrStr: "unsupported digest algorithm md5, supported are: blake2b, sha256, sha512",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verify.Digests(strings.NewReader("abc"), tt.algorithms)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("Digests() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil && err.Error() != tt.wantErrStr {
+				t.Errorf("Digests() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err == nil && !cmp.Equal(got, tt.want) {
+				t.Errorf("Digests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigests(t *testing.T) {
+	tests := []struct {
+		name       string
+		expected   map[string]string
+		wantErrStr string
+	}{
+		{
+			name: "matching digests",
+			expected: map[string]string{
+				"sha256":  abcSHA256,
+				"sha512":  strings.ToUpper(abcSHA512),
+				"blake2b": abcBLAKE2b,
+			},
+		},
+		{
+			name: "mismatching digest",
+			expected: map[string]string{
+				"sha256": abcSHA256,
+				"sha512": abcSHA256,
+			},
+			wantErrStr: "SHA512 hash mismatch, wanted " + abcSHA256 + ", got " + abcSHA512,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify.CheckDigests(strings.NewReader("abc"), tt.expected)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("CheckDigests() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil && err.Error() != tt.wantErrStr {
+				t.Errorf("CheckDigests() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	inTotoPayloadType         = "application/vnd.in-toto+json"
+	slsaProvenanceV02         = "https://slsa.dev/provenance/v0.2"
+	slsaProvenanceV1          = "https://slsa.dev/provenance/v1"
+	maxProvenanceLineCapacity = 16 * 1024 * 1024
+)
+
+// dsseEnvelope is a DSSE envelope, see
+// https://github.com/secure-systems-lab/dsse/blob/master/envelope.md.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+		Sig   string `json:"sig"`
+		// Cert is the Fulcio certificate of keyless signatures, as published
+		// by the SLSA GitHub generator
+		Cert string `json:"cert"`
+	} `json:"signatures"`
+
+	// keyless is true if the envelope was signed with a certificate instead of
+	// a public key
+	keyless bool
+}
+
+// sigstoreBundle is the part of a Sigstore bundle that wraps a DSSE envelope.
+type sigstoreBundle struct {
+	DSSEEnvelope         *dsseEnvelope `json:"dsseEnvelope"`
+	VerificationMaterial struct {
+		Certificate          json.RawMessage `json:"certificate"`
+		X509CertificateChain json.RawMessage `json:"x509CertificateChain"`
+	} `json:"verificationMaterial"`
+}
+
+// errKeylessProvenance is returned for attestations that were signed keylessly,
+// with a Fulcio certificate and a Rekor entry, which can't be verified yet.
+var errKeylessProvenance = errors.New(
+	"keyless provenance is not supported, the attestation has to be signed with a public key",
+)
+
+// inTotoStatement is an in-toto attestation statement with a SLSA provenance
+// predicate. Only the fields needed for verification are decoded.
+type inTotoStatement struct {
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	PredicateType string `json:"predicateType"`
+	Predicate     struct {
+		// SLSA provenance v0.2
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		// SLSA provenance v1
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// builderID returns the builder ID of the statement, for both supported
+// versions of the SLSA provenance predicate.
+func (s inTotoStatement) builderID() (string, error) {
+	switch s.PredicateType {
+	case slsaProvenanceV02:
+		return s.Predicate.Builder.ID, nil
+	case slsaProvenanceV1:
+		return s.Predicate.RunDetails.Builder.ID, nil
+	default:
+		return "", fmt.Errorf("unsupported predicate type: %s", s.PredicateType)
+	}
+}
+
+// Provenance verifies a SLSA provenance attestation for an artifact. The
+// attestation may be a DSSE envelope, a JSON lines file of DSSE envelopes (as
+// published by the SLSA GitHub generator) or a Sigstore bundle. The artifact's
+// SHA256 digest has to appear in the subject of the attestation, and the
+// builder ID has to match one of the trusted builder IDs. A trusted builder ID
+// without a version ("@ref") matches all versions of that builder. The
+// envelope has to be signed with the public key, as anyone who can serve the
+// attestation could forge an unsigned statement. Keyless attestations, like
+// those of the SLSA GitHub generator, are rejected, as verifying them requires
+// checking the certificate against the Sigstore roots and the Rekor log.
+func Provenance(
+	attestation []byte, sha256 string, trustedBuilderIDs []string,
+	publicKey string,
+) error {
+	if len(trustedBuilderIDs) == 0 {
+		return fmt.Errorf("no trusted builder IDs configured")
+	}
+
+	envelopes, err := parseDSSEEnvelopes(attestation)
+	if err != nil {
+		return err
+	}
+	for _, envelope := range envelopes {
+		if envelope.keyless {
+			return errKeylessProvenance
+		}
+	}
+	if publicKey == "" {
+		return fmt.Errorf("no public key for provenance")
+	}
+
+	// Statements for other artifacts may be in the same file, so look for one
+	// that covers the artifact
+	var errs []error
+	for _, envelope := range envelopes {
+		err = provenanceEnvelope(envelope, sha256, trustedBuilderIDs, publicKey)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return fmt.Errorf("no attestation matches: %w", errors.Join(errs...))
+}
+
+// parseDSSEEnvelopes parses all DSSE envelopes of an attestation.
+func parseDSSEEnvelopes(attestation []byte) (envelopes []dsseEnvelope, err error) {
+	// A single envelope or bundle may be pretty-printed over multiple lines
+	trimmed := bytes.TrimSpace(attestation)
+	if json.Valid(trimmed) {
+		return appendDSSEEnvelope(envelopes, trimmed)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(attestation))
+	scanner.Buffer(nil, maxProvenanceLineCapacity)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		envelopes, err = appendDSSEEnvelope(envelopes, line)
+		if err != nil {
+			return
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return
+	}
+
+	if len(envelopes) == 0 {
+		err = fmt.Errorf("no attestation found")
+	}
+	return
+}
+
+// appendDSSEEnvelope parses a DSSE envelope or a Sigstore bundle and appends
+// the envelope to the given list.
+func appendDSSEEnvelope(
+	envelopes []dsseEnvelope, contents []byte,
+) ([]dsseEnvelope, error) {
+	var bundle sigstoreBundle
+	err := json.Unmarshal(contents, &bundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation: %w", err)
+	}
+	if bundle.DSSEEnvelope != nil {
+		envelope := *bundle.DSSEEnvelope
+		envelope.keyless = len(bundle.VerificationMaterial.Certificate) > 0 ||
+			len(bundle.VerificationMaterial.X509CertificateChain) > 0
+		return append(envelopes, envelope), nil
+	}
+
+	var envelope dsseEnvelope
+	err = json.Unmarshal(contents, &envelope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation: %w", err)
+	}
+	for _, signature := range envelope.Signatures {
+		if signature.Cert != "" {
+			envelope.keyless = true
+		}
+	}
+	return append(envelopes, envelope), nil
+}
+
+// provenanceEnvelope verifies a single DSSE envelope.
+func provenanceEnvelope(
+	envelope dsseEnvelope, sha256 string, trustedBuilderIDs []string,
+	publicKey string,
+) error {
+	if envelope.PayloadType != inTotoPayloadType {
+		return fmt.Errorf("unsupported payload type: %s", envelope.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	err = dsseSignature(envelope, payload, publicKey)
+	if err != nil {
+		return err
+	}
+
+	var statement inTotoStatement
+	err = json.Unmarshal(payload, &statement)
+	if err != nil {
+		return fmt.Errorf("invalid statement: %w", err)
+	}
+
+	subjectFound := false
+	for _, subject := range statement.Subject {
+		if strings.EqualFold(subject.Digest["sha256"], sha256) {
+			subjectFound = true
+			break
+		}
+	}
+	if !subjectFound {
+		return fmt.Errorf("SHA256 digest %s not found in the attestation subject", sha256)
+	}
+
+	builderID, err := statement.builderID()
+	if err != nil {
+		return err
+	}
+	for _, trustedBuilderID := range trustedBuilderIDs {
+		if builderID == trustedBuilderID ||
+			(!strings.Contains(trustedBuilderID, "@") &&
+				strings.HasPrefix(builderID, trustedBuilderID+"@")) {
+			return nil
+		}
+	}
+	return fmt.Errorf("builder %s is not trusted", builderID)
+}
+
+// dsseSignature verifies that at least one signature of a DSSE envelope was
+// made with the given public key, in the same formats as cosign keys.
+func dsseSignature(envelope dsseEnvelope, payload []byte, publicKey string) error {
+	if len(envelope.Signatures) == 0 {
+		return fmt.Errorf("attestation is not signed")
+	}
+
+	// The pre-authentication encoding is what actually gets signed
+	pae := fmt.Sprintf(
+		"DSSEv1 %d %s %d %s",
+		len(envelope.PayloadType), envelope.PayloadType, len(payload), payload,
+	)
+
+	var err error
+	for _, signature := range envelope.Signatures {
+		err = cosignSignature(
+			publicKey, []byte(signature.Sig), strings.NewReader(pae),
+		)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid attestation signature: %w", err)
+}
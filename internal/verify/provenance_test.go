@@ -0,0 +1,214 @@
+package verify_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/toolctl/toolctl/internal/verify"
+)
+
+const testBuilderID = "https://github.com/slsa-framework/slsa-github-generator" +
+	"/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.9.0"
+
+func TestProvenance(t *testing.T) {
+	publicKey, privateKey := generateCosignKey(t)
+	otherPublicKey, _ := generateCosignKey(t)
+	artifactSHA256 := fmt.Sprintf("%x", sha256.Sum256(testData))
+
+	v02 := provenanceStatement(t, "https://slsa.dev/provenance/v0.2", artifactSHA256, testBuilderID)
+	v1 := provenanceStatement(t, "https://slsa.dev/provenance/v1", artifactSHA256, testBuilderID)
+	otherArtifact := provenanceStatement(
+		t, "https://slsa.dev/provenance/v1", strings.Repeat("0", 64), testBuilderID,
+	)
+	otherBuilder := provenanceStatement(
+		t, "https://slsa.dev/provenance/v1", artifactSHA256, "https://example.com/builder@v1",
+	)
+
+	tests := []struct {
+		name              string
+		attestation       string
+		trustedBuilderIDs []string
+		publicKey         string
+		wantErrStr        string
+	}{
+		{
+			name:              "SLSA v0.2 envelope",
+			attestation:       dsseEnvelope(t, v02, privateKey),
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+		},
+		{
+			name:        "SLSA v1 envelope, builder without version",
+			attestation: dsseEnvelope(t, v1, privateKey),
+			trustedBuilderIDs: []string{
+				"https://github.com/slsa-framework/slsa-github-generator" +
+					"/.github/workflows/generator_generic_slsa3.yml",
+			},
+			publicKey: publicKey,
+		},
+		{
+			name: "Sigstore bundle",
+			attestation: `{"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2", ` +
+				`"dsseEnvelope": ` + dsseEnvelope(t, v1, privateKey) + `}`,
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+		},
+		{
+			name: "JSON lines with multiple envelopes",
+			attestation: dsseEnvelope(t, otherArtifact, privateKey) + "\n" +
+				dsseEnvelope(t, v1, privateKey) + "\n",
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+		},
+		{
+			name:              "envelope signed with different key",
+			attestation:       dsseEnvelope(t, v1, privateKey),
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         otherPublicKey,
+			wantErrStr:        "invalid attestation signature: invalid cosign signature",
+		},
+		{
+			name:              "unsigned envelope with key",
+			attestation:       dsseEnvelope(t, v1, nil),
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+			wantErrStr:        "attestation is not signed",
+		},
+		{
+			name:              "unsigned envelope without key",
+			attestation:       dsseEnvelope(t, v1, nil),
+			trustedBuilderIDs: []string{testBuilderID},
+			wantErrStr:        "no public key for provenance",
+		},
+		{
+			name: "keyless envelope",
+			attestation: strings.Replace(
+				dsseEnvelope(t, v1, privateKey), `"keyid"`,
+				`"cert":"-----BEGIN CERTIFICATE-----\nMIIC\n-----END CERTIFICATE-----\n","keyid"`, 1,
+			),
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+			wantErrStr:        "keyless provenance is not supported, the attestation has to be signed with a public key",
+		},
+		{
+			name: "keyless Sigstore bundle without key",
+			attestation: `{"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json", ` +
+				`"verificationMaterial": {"certificate": {"rawBytes": "MIIC"}}, ` +
+				`"dsseEnvelope": ` + dsseEnvelope(t, v1, privateKey) + `}`,
+			trustedBuilderIDs: []string{testBuilderID},
+			wantErrStr:        "keyless provenance is not supported, the attestation has to be signed with a public key",
+		},
+		{
+			name:              "digest not in subject",
+			attestation:       dsseEnvelope(t, otherArtifact, privateKey),
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+			wantErrStr:        "SHA256 digest " + artifactSHA256 + " not found in the attestation subject",
+		},
+		{
+			name:              "untrusted builder",
+			attestation:       dsseEnvelope(t, otherBuilder, privateKey),
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+			wantErrStr:        "builder https://example.com/builder@v1 is not trusted",
+		},
+		{
+			name:              "builder prefix without separator",
+			attestation:       dsseEnvelope(t, otherBuilder, privateKey),
+			trustedBuilderIDs: []string{"https://example.com/build"},
+			publicKey:         publicKey,
+			wantErrStr:        "builder https://example.com/builder@v1 is not trusted",
+		},
+		{
+			name:        "no trusted builders",
+			attestation: dsseEnvelope(t, v1, privateKey),
+			publicKey:   publicKey,
+			wantErrStr:  "no trusted builder IDs configured",
+		},
+		{
+			name:              "invalid attestation",
+			attestation:       "not an attestation",
+			trustedBuilderIDs: []string{testBuilderID},
+			publicKey:         publicKey,
+			wantErrStr:        "invalid attestation: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify.Provenance(
+				[]byte(tt.attestation), artifactSHA256, tt.trustedBuilderIDs, tt.publicKey,
+			)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("Provenance() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil && err.Error() != tt.wantErrStr {
+				t.Errorf("Provenance() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+		})
+	}
+}
+
+func provenanceStatement(
+	t *testing.T, predicateType, sha256, builderID string,
+) []byte {
+	predicate := map[string]any{
+		"builder": map[string]any{"id": builderID},
+	}
+	if predicateType == "https://slsa.dev/provenance/v1" {
+		predicate = map[string]any{
+			"buildDefinition": map[string]any{"buildType": "https://example.com/build"},
+			"runDetails": map[string]any{
+				"builder": map[string]any{"id": builderID},
+			},
+		}
+	}
+
+	statement, err := json.Marshal(map[string]any{
+		"_type": "https://in-toto.io/Statement/v1",
+		"subject": []map[string]any{
+			{
+				"name":   "toolctl-test-tool.tar.gz",
+				"digest": map[string]string{"sha256": sha256},
+			},
+		},
+		"predicateType": predicateType,
+		"predicate":     predicate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statement
+}
+
+// dsseEnvelope wraps a statement in a DSSE envelope, signed with the given
+// key if it isn't nil.
+func dsseEnvelope(t *testing.T, statement []byte, privateKey *ecdsa.PrivateKey) string {
+	const payloadType = "application/vnd.in-toto+json"
+
+	signatures := []map[string]string{}
+	if privateKey != nil {
+		pae := fmt.Sprintf(
+			"DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(statement), statement,
+		)
+		signatures = append(signatures, map[string]string{
+			"keyid": "",
+			"sig":   string(cosignSign(t, privateKey, []byte(pae))),
+		})
+	}
+
+	envelope, err := json.Marshal(map[string]any{
+		"payloadType": payloadType,
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"signatures":  signatures,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(envelope)
+}
@@ -0,0 +1,187 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// Minisign is the signature type for minisign (Ed25519) signatures.
+	Minisign = "minisign"
+	// Cosign is the signature type for cosign blob signatures.
+	Cosign = "cosign"
+)
+
+const (
+	minisignAlgorithmLegacy    = "Ed"
+	minisignAlgorithmPrehashed = "ED"
+	minisignKeyIDLength        = 8
+	minisignTrustedComment     = "trusted comment: "
+	minisignUntrustedComment   = "untrusted comment: "
+)
+
+// Signature verifies the signature of the data read from the given reader,
+// using the given public key. The signature type determines the format of the
+// public key and the signature.
+func Signature(
+	signatureType string, publicKey string, signature []byte, data io.Reader,
+) error {
+	switch signatureType {
+	case Minisign:
+		return minisignSignature(publicKey, signature, data)
+	case Cosign:
+		return cosignSignature(publicKey, signature, data)
+	default:
+		return fmt.Errorf("unsupported signature type: %s", signatureType)
+	}
+}
+
+// minisignSignature verifies a minisign signature.
+// See https://jedisct1.github.io/minisign/ for the format.
+func minisignSignature(publicKey string, signature []byte, data io.Reader) error {
+	keyBytes, err := decodeMinisignLine(lastMinisignLine(publicKey))
+	if err != nil {
+		return fmt.Errorf("invalid minisign public key: %w", err)
+	}
+	if len(keyBytes) != 2+minisignKeyIDLength+ed25519.PublicKeySize ||
+		string(keyBytes[:2]) != minisignAlgorithmLegacy {
+		return fmt.Errorf("invalid minisign public key")
+	}
+	keyID := keyBytes[2 : 2+minisignKeyIDLength]
+	key := ed25519.PublicKey(keyBytes[2+minisignKeyIDLength:])
+
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 ||
+		!strings.HasPrefix(lines[0], minisignUntrustedComment) ||
+		!strings.HasPrefix(lines[2], minisignTrustedComment) {
+		return fmt.Errorf("invalid minisign signature")
+	}
+
+	sigBytes, err := decodeMinisignLine(lines[1])
+	if err != nil || len(sigBytes) != 2+minisignKeyIDLength+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	algorithm := string(sigBytes[:2])
+	sig := sigBytes[2+minisignKeyIDLength:]
+
+	if !bytes.Equal(sigBytes[2:2+minisignKeyIDLength], keyID) {
+		return fmt.Errorf("minisign signature was created with a different key")
+	}
+
+	var message []byte
+	switch algorithm {
+	case minisignAlgorithmLegacy:
+		message, err = io.ReadAll(data)
+		if err != nil {
+			return err
+		}
+	case minisignAlgorithmPrehashed:
+		hash, _ := blake2b.New512(nil)
+		if _, err = io.Copy(hash, data); err != nil {
+			return err
+		}
+		message = hash.Sum(nil)
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm: %s", algorithm)
+	}
+
+	if !ed25519.Verify(key, message, sig) {
+		return fmt.Errorf("invalid minisign signature")
+	}
+
+	// The global signature covers the signature and the trusted comment
+	globalSig, err := decodeMinisignLine(lines[3])
+	if err != nil {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), minisignTrustedComment)
+	if !ed25519.Verify(key, append(sig, []byte(trustedComment)...), globalSig) {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+
+	return nil
+}
+
+// lastMinisignLine returns the last line of a minisign file that is not a
+// comment, which makes it possible to pass either a key file or a bare key.
+func lastMinisignLine(contents string) (line string) {
+	for _, l := range strings.Split(strings.TrimSpace(contents), "\n") {
+		if !strings.HasPrefix(l, minisignUntrustedComment) {
+			line = l
+		}
+	}
+	return
+}
+
+func decodeMinisignLine(line string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(line))
+}
+
+// cosignSignature verifies a signature created by `cosign sign-blob --key`.
+// The signature may be base64-encoded, which is what cosign outputs by default.
+func cosignSignature(publicKey string, signature []byte, data io.Reader) error {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return fmt.Errorf("invalid cosign public key: no PEM data found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid cosign public key: %w", err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		sig = signature
+	}
+
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		message, err := io.ReadAll(data)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(key, message, sig) {
+			return fmt.Errorf("invalid cosign signature")
+		}
+	case *ecdsa.PublicKey:
+		digest, err := sha256Digest(data)
+		if err != nil {
+			return err
+		}
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			return fmt.Errorf("invalid cosign signature")
+		}
+	case *rsa.PublicKey:
+		digest, err := sha256Digest(data)
+		if err != nil {
+			return err
+		}
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, sig) != nil {
+			return fmt.Errorf("invalid cosign signature")
+		}
+	default:
+		return fmt.Errorf("unsupported cosign public key type: %T", key)
+	}
+
+	return nil
+}
+
+func sha256Digest(data io.Reader) ([]byte, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, data); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,198 @@
+package verify_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/toolctl/toolctl/internal/verify"
+	"golang.org/x/crypto/blake2b"
+)
+
+var testData = []byte("toolctl test data")
+
+func TestSignature(t *testing.T) {
+	minisignPublicKey, minisignPrivateKey := generateMinisignKey(t)
+	_, otherMinisignPrivateKey := generateMinisignKey(t)
+	cosignPublicKey, cosignPrivateKey := generateCosignKey(t)
+
+	tests := []struct {
+		name          string
+		signatureType string
+		publicKey     string
+		signature     []byte
+		data          []byte
+		wantErrStr    string
+	}{
+		{
+			name:          "minisign",
+			signatureType: verify.Minisign,
+			publicKey:     minisignPublicKey,
+			signature:     minisignSign(t, minisignPrivateKey, testData, false),
+			data:          testData,
+		},
+		{
+			name:          "minisign prehashed",
+			signatureType: verify.Minisign,
+			publicKey:     minisignPublicKey,
+			signature:     minisignSign(t, minisignPrivateKey, testData, true),
+			data:          testData,
+		},
+		{
+			name:          "minisign with tampered data",
+			signatureType: verify.Minisign,
+			publicKey:     minisignPublicKey,
+			signature:     minisignSign(t, minisignPrivateKey, testData, true),
+			data:          []byte("tampered"),
+			wantErrStr:    "invalid minisign signature",
+		},
+		{
+			name:          "minisign with different key",
+			signatureType: verify.Minisign,
+			publicKey:     minisignPublicKey,
+			signature:     minisignSign(t, otherMinisignPrivateKey, testData, true),
+			data:          testData,
+			wantErrStr:    "minisign signature was created with a different key",
+		},
+		{
+			name:          "minisign with tampered trusted comment",
+			signatureType: verify.Minisign,
+			publicKey:     minisignPublicKey,
+			signature: bytes.Replace(
+				minisignSign(t, minisignPrivateKey, testData, true),
+				[]byte("timestamp"), []byte("tampered"), 1,
+			),
+			data:       testData,
+			wantErrStr: "invalid minisign global signature",
+		},
+		{
+			name:          "cosign",
+			signatureType: verify.Cosign,
+			publicKey:     cosignPublicKey,
+			signature:     cosignSign(t, cosignPrivateKey, testData),
+			data:          testData,
+		},
+		{
+			name:          "cosign with tampered data",
+			signatureType: verify.Cosign,
+			publicKey:     cosignPublicKey,
+			signature:     cosignSign(t, cosignPrivateKey, testData),
+			data:          []byte("tampered"),
+			wantErrStr:    "invalid cosign signature",
+		},
+		{
+			name:          "cosign with invalid public key",
+			signatureType: verify.Cosign,
+			publicKey:     minisignPublicKey,
+			signature:     cosignSign(t, cosignPrivateKey, testData),
+			data:          testData,
+			wantErrStr:    "invalid cosign public key: no PEM data found",
+		},
+		{
+			name:          "unsupported signature type",
+			signatureType: "gpg",
+			data:          testData,
+			wantErrStr:    "unsupported signature type: gpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify.Signature(
+				tt.signatureType, tt.publicKey, tt.signature, bytes.NewReader(tt.data),
+			)
+			if (err == nil) != (tt.wantErrStr == "") {
+				t.Fatalf("Signature() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+			if err != nil && err.Error() != tt.wantErrStr {
+				t.Errorf("Signature() error = %v, wantErr %v", err, tt.wantErrStr)
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Test helpers
+// -----------------------------------------------------------------------------
+
+type minisignPrivateKey struct {
+	keyID []byte
+	key   ed25519.PrivateKey
+}
+
+// generateMinisignKey generates a minisign key pair and returns the public key
+// in the format of a minisign public key file.
+func generateMinisignKey(t *testing.T) (string, minisignPrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := make([]byte, 8)
+	if _, err = rand.Read(keyID); err != nil {
+		t.Fatal(err)
+	}
+
+	encodedPublicKey := base64.StdEncoding.EncodeToString(
+		append(append([]byte("Ed"), keyID...), publicKey...),
+	)
+	return "untrusted comment: minisign public key\n" + encodedPublicKey + "\n",
+		minisignPrivateKey{keyID: keyID, key: privateKey}
+}
+
+// minisignSign creates a minisign signature file for the given data.
+func minisignSign(
+	t *testing.T, privateKey minisignPrivateKey, data []byte, prehashed bool,
+) []byte {
+	t.Helper()
+
+	algorithm := "Ed"
+	message := data
+	if prehashed {
+		algorithm = "ED"
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	}
+
+	sig := ed25519.Sign(privateKey.key, message)
+	trustedComment := "timestamp:1700000000\tfile:toolctl-test-tool"
+	globalSig := ed25519.Sign(privateKey.key, append(sig, []byte(trustedComment)...))
+
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(
+			append(append([]byte(algorithm), privateKey.keyID...), sig...),
+		) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n")
+}
+
+// generateCosignKey generates an ECDSA key pair, like `cosign generate-key-pair`,
+// and returns the public key in PEM format.
+func generateCosignKey(t *testing.T) (string, *ecdsa.PrivateKey) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		privateKey
+}
+
+// cosignSign creates a base64-encoded signature, like `cosign sign-blob`.
+func cosignSign(t *testing.T, privateKey *ecdsa.PrivateKey, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sig))
+}
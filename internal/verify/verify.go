@@ -0,0 +1,3 @@
+// Package verify contains the checks that downloaded artifacts have to pass
+// before they are trusted.
+package verify